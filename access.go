@@ -0,0 +1,123 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// AccessReport the config read access report.
+type AccessReport struct {
+	// Hits read count of each accessed key path
+	Hits map[string]int
+	// Unused leaf key paths that were never read
+	Unused []string
+}
+
+// AccessedKeys get all accessed key paths and read count.
+// Require the option TrackAccess is enabled.
+func (c *Config) AccessedKeys() map[string]int {
+	c.accessLock.Lock()
+	defer c.accessLock.Unlock()
+
+	hits := make(map[string]int, len(c.accessed))
+	for key, n := range c.accessed {
+		hits[key] = n
+	}
+	return hits
+}
+
+// ResetAccess clear the access records
+func (c *Config) ResetAccess() {
+	c.accessLock.Lock()
+	c.accessed = nil
+	c.accessLock.Unlock()
+}
+
+// AccessReport compare the access records with all keys of the config data,
+// and collect the leaf keys that were never read.
+//
+// Usage:
+//
+//	c := config.NewWithOptions("app", config.TrackAccess)
+//	// ... load and read config
+//	for _, key := range c.AccessReport().Unused {
+//		fmt.Println("unused key:", key)
+//	}
+func (c *Config) AccessReport() *AccessReport {
+	hits := c.AccessedKeys()
+	sep := string(c.opts.Delimiter)
+
+	report := &AccessReport{Hits: hits}
+	for _, key := range leafKeys(c.data, sep) {
+		if !isAccessedKey(key, hits, sep) {
+			report.Unused = append(report.Unused, key)
+		}
+	}
+
+	sort.Strings(report.Unused)
+	return report
+}
+
+// record a read access of the key path
+func (c *Config) recordAccess(key string) {
+	c.accessLock.Lock()
+	if c.accessed == nil {
+		c.accessed = make(map[string]int)
+	}
+	c.accessed[key]++
+	c.accessLock.Unlock()
+}
+
+// check leaf key is accessed. the key itself, the parent key or an element of it has been read.
+func isAccessedKey(key string, hits map[string]int, sep string) bool {
+	if _, ok := hits[key]; ok {
+		return true
+	}
+
+	for hitKey := range hits {
+		if strings.HasPrefix(key, hitKey+sep) || strings.HasPrefix(hitKey, key+sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// collect all leaf key paths of the data. slice values are as a leaf.
+func leafKeys(data interface{}, sep string) (keys []string) {
+	collectLeafKeys(data, "", sep, &keys)
+	return
+}
+
+func collectLeafKeys(data interface{}, prefix, sep string, keys *[]string) {
+	addKey := func(k string, v interface{}) {
+		if prefix != "" {
+			k = prefix + sep + k
+		}
+		collectLeafKeys(v, k, sep, keys)
+	}
+
+	switch typeData := data.(type) {
+	case map[string]interface{}:
+		for k, v := range typeData {
+			addKey(k, v)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range typeData {
+			addKey(strutil.MustString(k), v)
+		}
+	case map[string]string:
+		for k, v := range typeData {
+			addKey(k, v)
+		}
+	case map[string]int:
+		for k, v := range typeData {
+			addKey(k, v)
+		}
+	default:
+		if prefix != "" {
+			*keys = append(*keys, prefix)
+		}
+	}
+}
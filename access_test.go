@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_AccessReport(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", TrackAccess)
+	err := c.LoadStrings(JSON, jsonStr)
+	is.NoError(err)
+
+	is.Equal("app", c.String("name"))
+	is.Equal("app", c.String("name"))
+	is.True(c.Bool("debug"))
+	is.Equal("val1", c.String("map1.key1"))
+	is.Len(c.Strings("arr1"), 3)
+
+	hits := c.AccessedKeys()
+	is.Equal(2, hits["name"])
+	is.Equal(1, hits["debug"])
+	is.Equal(1, hits["map1.key1"])
+	is.NotContains(hits, "age")
+
+	report := c.AccessReport()
+	is.Contains(report.Unused, "age")
+	is.Contains(report.Unused, "map1.key")
+	is.NotContains(report.Unused, "name")
+	is.NotContains(report.Unused, "arr1")
+	is.NotContains(report.Unused, "map1.key1")
+
+	// read parent key, all sub keys are accessed
+	mp := map[string]string{}
+	is.NoError(c.Structure("map1", &mp))
+	is.NotContains(c.AccessReport().Unused, "map1.key")
+
+	c.ResetAccess()
+	is.Empty(c.AccessedKeys())
+	is.Contains(c.AccessReport().Unused, "name")
+
+	// disabled
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, jsonStr))
+	c.String("name")
+	is.Empty(c.AccessedKeys())
+}
+
+func TestConfig_AccessReport_refs(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", TrackAccess, ParseRefs)
+	is.NoError(c.LoadStrings(JSON, `{"host": "localhost", "url": "http://${host}", "port": 80}`))
+
+	// dump data will not record the referenced keys
+	buf := new(bytes.Buffer)
+	_, err := c.DumpTo(buf, JSON)
+	is.NoError(err)
+	is.Contains(buf.String(), "http://localhost")
+	is.Empty(c.AccessedKeys())
+	is.Equal("http://localhost", c.Data()["url"])
+	is.Empty(c.AccessedKeys())
+
+	// read the value will record the referenced keys
+	is.Equal("http://localhost", c.String("url"))
+	hits := c.AccessedKeys()
+	is.Equal(1, hits["url"])
+	is.Equal(1, hits["host"])
+	is.NotContains(hits, "port")
+}
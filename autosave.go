@@ -0,0 +1,182 @@
+package config
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// AutoSaveOptions options for auto save the config data to file on data changed.
+type AutoSaveOptions struct {
+	// File the file for save data
+	File string
+	// Format the save format. if is empty, will use the file ext as format.
+	Format string
+	// Debounce the changes in the window will be saved once.
+	// if is 0, will save data immediately on each change.
+	Debounce time.Duration
+	// OnError func for handle the save error
+	OnError func(err error)
+	// Errors channel for receive the save error. the error will be dropped on channel is full.
+	//
+	// NOTICE: if OnError and Errors are both not set, the error will be recorded, see Config.Error()
+	Errors chan<- error
+	// DumpOptions options for dump data to the file. see DumpToFile()
+	DumpOptions []func(*DumpOptions)
+}
+
+// WithAutoSave enable auto save data to the file on data changed by Set(), SetData().
+//
+// Usage:
+//
+//	c := config.NewWithOptions("app", config.WithAutoSave("app.json", func(o *config.AutoSaveOptions) {
+//		o.Debounce = time.Second
+//		o.OnError = func(err error) { log.Println(err) }
+//	}))
+//	defer c.Close() // flush the pending changes
+func WithAutoSave(file string, fns ...func(*AutoSaveOptions)) func(*Options) {
+	return func(opts *Options) {
+		asOpts := &AutoSaveOptions{File: file}
+		for _, fn := range fns {
+			fn(asOpts)
+		}
+		opts.AutoSave = asOpts
+	}
+}
+
+// Close the config, will flush the pending auto save changes.
+func Close() error { return dc.Close() }
+
+// Close the config, will wait for the async events are dispatched, and
+// flush the pending auto save changes. see AsyncEvents, WithAutoSave()
+func (c *Config) Close() error {
+	if c.root != nil {
+		return nil
+	}
+
+	c.events.wait()
+	if c.saver == nil {
+		return nil
+	}
+	return c.saver.close()
+}
+
+// trigger auto save on data changed
+func (c *Config) autoSave(event string) {
+	if c.opts.AutoSave == nil || !strings.HasPrefix(event, "set.") {
+		return
+	}
+
+	c.saverOnce.Do(func() {
+		c.saver = &autoSaver{c: c, opts: c.opts.AutoSave}
+	})
+	c.saver.trigger()
+}
+
+// autoSaver save the config data to file, coalesce the changes in the debounce window.
+type autoSaver struct {
+	c    *Config
+	opts *AutoSaveOptions
+
+	lock   sync.Mutex
+	timer  *time.Timer
+	dirty  bool
+	closed bool
+	// wait for the running flush on close
+	wg sync.WaitGroup
+	// serialize the writes
+	saveLock sync.Mutex
+}
+
+// trigger on data changed
+func (s *autoSaver) trigger() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+
+	if s.opts.Debounce <= 0 {
+		s.lock.Unlock()
+		s.report(s.save())
+		return
+	}
+	defer s.lock.Unlock()
+
+	s.dirty = true
+	// the window is started by the first change, not reset by later changes.
+	if s.timer == nil {
+		s.wg.Add(1)
+		s.timer = time.AfterFunc(s.opts.Debounce, s.flush)
+	}
+}
+
+// flush the pending changes
+func (s *autoSaver) flush() {
+	defer s.wg.Done()
+
+	s.lock.Lock()
+	s.timer = nil
+	dirty := s.dirty
+	s.dirty = false
+	s.lock.Unlock()
+
+	if dirty {
+		s.report(s.save())
+	}
+}
+
+func (s *autoSaver) close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	if s.timer != nil && s.timer.Stop() {
+		s.wg.Done()
+	}
+	s.timer = nil
+	dirty := s.dirty
+	s.dirty = false
+	s.lock.Unlock()
+
+	// the flush is running, wait for it done.
+	s.wg.Wait()
+	if !dirty {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *autoSaver) save() error {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+
+	s.c.lock.RLock()
+	defer s.c.lock.RUnlock()
+	return s.c.DumpToFile(s.opts.File, s.opts.Format, s.opts.DumpOptions...)
+}
+
+// report the save error
+func (s *autoSaver) report(err error) {
+	if err == nil {
+		return
+	}
+
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+
+	if s.opts.Errors != nil {
+		select {
+		case s.opts.Errors <- err:
+		default:
+		}
+	}
+
+	if s.opts.OnError == nil && s.opts.Errors == nil {
+		s.c.addError(err)
+	}
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAutoSave(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := NewWithOptions("test", WithAutoSave(file))
+	is.NotNil(c.Options().AutoSave)
+	is.Equal(file, c.Options().AutoSave.File)
+
+	// save immediately
+	is.NoError(c.Set("name", "app"))
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"name":"app"}`, string(bts))
+
+	// not save on load data
+	is.NoError(c.LoadStrings(JSON, `{"age": 23}`))
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"name":"app"}`, string(bts))
+
+	is.NoError(c.Set("debug", true))
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"age":23,"debug":true,"name":"app"}`, string(bts))
+
+	// view does not save data itself
+	sub := c.Sub("db")
+	is.Nil(sub.Options().AutoSave)
+	is.NoError(sub.Set("port", 3306))
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Contains(string(bts), `"db":{"port":3306}`)
+
+	// closed, changes are not saved
+	is.NoError(c.Close())
+	is.NoError(c.Set("name", "new-app"))
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Contains(string(bts), `"name":"app"`)
+}
+
+func TestWithAutoSave_debounce(t *testing.T) {
+	is := assert.New(t)
+
+	var fired, saved int32
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := NewWithOptions("test", WithAutoSave(file, func(o *AutoSaveOptions) {
+		o.Debounce = 50 * time.Millisecond
+		o.DumpOptions = []func(*DumpOptions){func(*DumpOptions) {
+			atomic.AddInt32(&saved, 1)
+		}}
+	}), WithHookFunc(func(event string, c *Config) {
+		atomic.AddInt32(&fired, 1)
+	}))
+
+	for i := 0; i < 10; i++ {
+		is.NoError(c.Set("num", i))
+	}
+
+	// the hook func is still fired
+	is.Equal(int32(10), atomic.LoadInt32(&fired))
+
+	// not saved before the window end
+	_, err := os.Stat(file)
+	is.True(os.IsNotExist(err))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&saved) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// coalesce the changes to one write
+	is.NoError(c.Close())
+	is.Equal(int32(1), atomic.LoadInt32(&saved))
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"num":9}`, string(bts))
+}
+
+func TestWithAutoSave_loadData(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := NewWithOptions("test", WithAutoSave(file, func(o *AutoSaveOptions) {
+		o.Debounce = time.Millisecond
+	}))
+
+	// load data while the pending changes are flushing, check by the "-race"
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				is.NoError(c.Set("num", j))
+				is.NoError(c.LoadStrings(JSON, fmt.Sprintf(`{"db": {"port": %d}}`, j)))
+				is.NoError(c.LoadData(map[string]interface{}{"name": "app"}))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	is.NoError(c.Close())
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Contains(string(bts), `"num":19`)
+}
+
+func TestConfig_Close(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := NewWithOptions("test", WithAutoSave(file, func(o *AutoSaveOptions) {
+		o.Debounce = time.Hour
+	}))
+
+	is.NoError(c.Close())
+	is.NoError(c.Set("name", "app"))
+	is.NoError(c.Set("age", 23))
+
+	_, err := ioutil.ReadFile(file)
+	is.Error(err)
+
+	// flush on close
+	is.NoError(c.Close())
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"age":23,"name":"app"}`, string(bts))
+
+	// closed, changes are not saved
+	is.NoError(c.Set("age", 24))
+	is.NoError(c.Close())
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"age":23,"name":"app"}`, string(bts))
+
+	// close without auto save
+	is.NoError(New("test").Close())
+	is.NoError(Close())
+}
+
+func TestWithAutoSave_errors(t *testing.T) {
+	is := assert.New(t)
+
+	// error callback
+	var gotErr error
+	badFile := filepath.Join(t.TempDir(), "not-exists", "app.json")
+	c := NewWithOptions("test", WithAutoSave(badFile, func(o *AutoSaveOptions) {
+		o.OnError = func(err error) { gotErr = err }
+	}))
+	is.NoError(c.Set("name", "app"))
+	is.Error(gotErr)
+	is.NoError(c.Error())
+
+	// error channel
+	errCh := make(chan error, 1)
+	c = NewWithOptions("test", WithAutoSave(badFile, func(o *AutoSaveOptions) {
+		o.Errors = errCh
+	}))
+	is.NoError(c.Set("name", "app"))
+	is.NoError(c.Set("name", "app1")) // channel is full, will not block
+	is.Error(<-errCh)
+	is.NoError(c.Error())
+
+	// debounce with error channel
+	c = NewWithOptions("test", WithAutoSave(badFile, func(o *AutoSaveOptions) {
+		o.Errors = errCh
+		o.Debounce = 10 * time.Millisecond
+	}))
+	is.NoError(c.Set("name", "app"))
+	select {
+	case err := <-errCh:
+		is.Error(err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("not report the error")
+	}
+
+	// error on close
+	c = NewWithOptions("test", WithAutoSave(badFile, func(o *AutoSaveOptions) {
+		o.Debounce = time.Hour
+	}))
+	is.NoError(c.Set("name", "app"))
+	is.Error(c.Close())
+
+	// record the error
+	c = NewWithOptions("test", WithAutoSave(filepath.Join(t.TempDir(), "app.json"), func(o *AutoSaveOptions) {
+		o.Format = "invalid"
+	}))
+	is.NoError(c.Set("name", "app"))
+	is.Error(c.Error())
+}
+
+func TestWithSetSaveFile(t *testing.T) {
+	is := assert.New(t)
+
+	var events []string
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := NewWithOptions("test", WithHookFunc(func(event string, c *Config) {
+		events = append(events, event)
+	}), WithSetSaveFile(file, JSON))
+
+	// keep the hook func
+	is.NotNil(c.Options().HookFunc)
+	is.NoError(c.Set("name", "app"))
+	is.Equal([]string{OnSetValue}, events)
+
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"name":"app"}`, string(bts))
+
+	// not panic on error, record it.
+	c = NewWithOptions("test", WithSetSaveFile(file, "invalid"))
+	is.NotPanics(func() {
+		is.NoError(c.Set("name", "app"))
+	})
+	is.Error(c.Error())
+}
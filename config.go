@@ -41,6 +41,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -50,8 +51,12 @@ const (
 	Hcl  = "hcl"
 	Yml  = "yml"
 	JSON = "json"
-	Yaml = "yaml"
-	Toml = "toml"
+	// NDJSON newline delimited JSON, each line is a JSON document.
+	NDJSON = "ndjson"
+	Yaml   = "yaml"
+	Toml   = "toml"
+	// DotEnv the .env file format. see the driver package dotenv
+	DotEnv = "env"
 
 	// default delimiter
 	defaultDelimiter byte = '.'
@@ -79,11 +84,46 @@ type Config struct {
 
 	// config options
 	opts *Options
-	// all config data
+	// the root config and key prefix, if is a view or a handle of other config. see Sub(), UseLayer()
+	root   *Config
+	prefix string
+	// the context for load data through the handle. see UseLayer(), MergeWith()
+	ctx loadCtx
+	// all config data, it's merged from the layers data
 	data map[string]interface{}
+	// data of each layer, and layer names ordered by priority
+	layers     map[string]map[string]interface{}
+	layerNames []string
+	// the merge options of the last loading for each layer, replayed on rebuild data
+	layerMerge map[string]*MergeOptions
+	// value resolvers for "${scheme:arg}". see AddResolver()
+	resolvers map[string]*resolver
+	resLock   sync.RWMutex
+	// the key paths will be encrypted on dump data. see EncryptKeys()
+	encryptKeys []string
+	// the key from the KeyProvider, cached on first use. see SetKeyProvider()
+	encKey  []byte
+	encLock sync.Mutex
+	// find value from ENV on read. see AutomaticEnv(), BindEnv()
+	autoEnv   bool
+	envPrefix string
+	envBinds  map[string][]string
+	// the sensitive key patterns. see AddSensitive()
+	sensitive []string
+	sensLock  sync.RWMutex
 
 	// loaded config files records
 	loadedFiles []string
+	// the files skipped on load dir or glob, the format is not supported. see LoadDir()
+	skippedFiles []string
+	// the candidate files checked by the last search. see LoadFirstFound()
+	searchedFiles []string
+	// the active profile and the loaded profile files. see LoadProfile()
+	profile      string
+	profileFiles []string
+	// the loaded files data and the keys changed by Set(). see SaveChanges()
+	sources     []*fileSource
+	changedKeys []string
 	driverNames []string
 
 	// TODO Deprecated decoder and encoder, use driver instead
@@ -93,6 +133,10 @@ type Config struct {
 	// decoders["yaml"] = func(blob []byte, v interface{}) (err error){}
 	decoders map[string]Decoder
 	encoders map[string]Encoder
+	// decoders for decode multi documents content. see DocsDriver
+	docsDecoders map[string]DocsDecoder
+	// drivers for decode and encode with the file path. see FileDriver
+	fileDrivers map[string]FileDriver
 
 	// cache on got config data
 	intCache map[string]int
@@ -101,6 +145,19 @@ type Config struct {
 	// iMapCache map[string]intMap
 	sArrCache map[string]strArr
 	sMapCache map[string]strMap
+	// the caches are cleared on data changed, the gen is for drop the values read before the change.
+	cacheGen  uint64
+	cacheLock sync.Mutex
+
+	// read access records, enable by option TrackAccess
+	accessed   map[string]int
+	accessLock sync.Mutex
+
+	// event subscribers. see Subscribe()
+	events eventBus
+	// auto save the data on changed. see WithAutoSave()
+	saver     *autoSaver
+	saverOnce sync.Once
 }
 
 // New config instance
@@ -153,32 +210,63 @@ func AddDriver(driver Driver) { dc.AddDriver(driver) }
 
 // AddDriver set a decoder and encoder driver for a format.
 func (c *Config) AddDriver(driver Driver) {
+	// the drivers are shared by the views
+	if c.root != nil {
+		c.root.AddDriver(driver)
+		return
+	}
+
 	format := driver.Name()
 
 	c.driverNames = append(c.driverNames, format)
 	c.decoders[format] = driver.GetDecoder()
 	c.encoders[format] = driver.GetEncoder()
+
+	if dd, ok := driver.(DocsDriver); ok && dd.GetDocsDecoder() != nil {
+		if c.docsDecoders == nil {
+			c.docsDecoders = make(map[string]DocsDecoder)
+		}
+		c.docsDecoders[format] = dd.GetDocsDecoder()
+	} else {
+		delete(c.docsDecoders, format)
+	}
+
+	if fd, ok := driver.(FileDriver); ok {
+		if c.fileDrivers == nil {
+			c.fileDrivers = make(map[string]FileDriver)
+		}
+		c.fileDrivers[format] = fd
+	} else {
+		delete(c.fileDrivers, format)
+	}
 }
 
 // HasDecoder has decoder
 func (c *Config) HasDecoder(format string) bool {
 	format = fixFormat(format)
-	_, ok := c.decoders[format]
+	_, ok := c.rootConfig().decoders[format]
 	return ok
 }
 
 // HasEncoder has encoder
 func (c *Config) HasEncoder(format string) bool {
 	format = fixFormat(format)
-	_, ok := c.encoders[format]
+	_, ok := c.rootConfig().encoders[format]
 	return ok
 }
 
 // DelDriver delete driver of the format
 func (c *Config) DelDriver(format string) {
+	if c.root != nil {
+		c.root.DelDriver(format)
+		return
+	}
+
 	format = fixFormat(format)
 	delete(c.decoders, format)
 	delete(c.encoders, format)
+	delete(c.docsDecoders, format)
+	delete(c.fileDrivers, format)
 }
 
 /*************************************************************
@@ -192,53 +280,118 @@ func (c *Config) Name() string {
 
 // Error get last error, will clear after read.
 func (c *Config) Error() error {
-	err := c.err
-	c.err = nil
+	root := c.rootConfig()
+	err := root.err
+	root.err = nil
 	return err
 }
 
 // IsEmpty of the config
 func (c *Config) IsEmpty() bool {
+	if c.root != nil {
+		return len(c.viewData()) == 0
+	}
 	return len(c.data) == 0
 }
 
 // LoadedFiles get loaded files name
 func (c *Config) LoadedFiles() []string {
-	return c.loadedFiles
+	return c.rootConfig().loadedFiles
 }
 
 // DriverNames get loaded driver names
 func (c *Config) DriverNames() []string {
-	return c.driverNames
+	return c.rootConfig().driverNames
 }
 
 // ClearAll data and caches
 func ClearAll() { dc.ClearAll() }
 
-// ClearAll data and caches
+// ClearAll data and caches. on a view, only clear the data under the view prefix.
 func (c *Config) ClearAll() {
+	if c.root != nil {
+		if c.prefix == "" {
+			c.root.ClearAll()
+		} else {
+			c.ClearData()
+		}
+		return
+	}
+
 	c.ClearData()
 	c.ClearCaches()
 
 	c.loadedFiles = []string{}
+	c.searchedFiles = nil
+	c.profile = ""
+	c.profileFiles = nil
 	c.opts.Readonly = false
 }
 
-// ClearData clear data
+// ClearData clear data. on a view, only clear the data under the view prefix.
 func (c *Config) ClearData() {
-	c.fireHook(OnCleanData)
+	if c.root != nil {
+		if c.prefix == "" {
+			c.root.ClearData()
+		} else {
+			c.clearView()
+		}
+		return
+	}
+
+	c.fireEvent(OnCleanData, "")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
 	c.data = make(map[string]interface{})
+	c.layers = nil
+	c.layerMerge = nil
 	c.loadedFiles = []string{}
+	c.skippedFiles = nil
+	c.sources = nil
+	c.changedKeys = nil
+}
+
+// remove the data under the view prefix from all layers
+func (c *Config) clearView() {
+	root := c.root
+	keys := strings.Split(c.prefix, string(c.opts.Delimiter))
+
+	root.lock.Lock()
+	for name, layer := range root.layers {
+		if _, ok := findByKeys(layer, keys); ok {
+			layer = deepCopyMap(layer)
+			deleteByKeys(layer, keys)
+			root.layers[name] = layer
+		}
+	}
+	err := root.rebuildData()
+	root.lock.Unlock()
+
+	if err != nil {
+		c.addError(err)
+		return
+	}
+	root.fireEvent(OnCleanData, "", c.prefix)
+	c.fireEvent(OnCleanData, "")
 }
 
 // ClearCaches clear caches
 func (c *Config) ClearCaches() {
+	if c.root != nil {
+		c.root.ClearCaches()
+		return
+	}
+
 	if c.opts.EnableCache {
+		c.cacheLock.Lock()
 		c.intCache = nil
 		c.strCache = nil
 		c.sMapCache = nil
 		c.sArrCache = nil
+		c.cacheGen++
+		c.cacheLock.Unlock()
 	}
 }
 
@@ -246,19 +399,12 @@ func (c *Config) ClearCaches() {
  * helper methods
  *************************************************************/
 
-// fire hook
-func (c *Config) fireHook(name string) {
-	if c.opts.HookFunc != nil {
-		c.opts.HookFunc(name, c)
-	}
-}
-
 // record error
 func (c *Config) addError(err error) {
-	c.err = err
+	c.rootConfig().err = err
 }
 
 // format and record error
 func (c *Config) addErrorf(format string, a ...interface{}) {
-	c.err = fmt.Errorf(format, a...)
+	c.addError(fmt.Errorf(format, a...))
 }
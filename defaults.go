@@ -0,0 +1,76 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetDefault set a default value for the key
+func SetDefault(key string, val interface{}) error { return dc.SetDefault(key, val) }
+
+// SetDefault set a default value for the key.
+//
+// The default values are stored in the lowest priority layer LayerDefaults,
+// so they can be read by GetValue, Exists, Structure and dumped by DumpTo.
+//
+// Usage:
+//
+//	c.SetDefault("db.port", 3306)
+//	c.Int("db.port") // 3306, if not set by other sources
+func (c *Config) SetDefault(key string, val interface{}) error {
+	return c.withLayer(LayerDefaults).Set(key, val)
+}
+
+// SetDefaults set multi default values
+func SetDefaults(values map[string]interface{}) error { return dc.SetDefaults(values) }
+
+// SetDefaults set multi default values, the map key can be key path. eg: "db.port"
+func (c *Config) SetDefaults(values map[string]interface{}) (err error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	// sort keys for set parent key before sub key.
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err = c.SetDefault(key, values[key]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Defaults get all default values. NOTICE: don't modify the returned data.
+func (c *Config) Defaults() map[string]interface{} {
+	return c.LayerData(LayerDefaults)
+}
+
+// NonDefaultData get config data without the default values.
+func (c *Config) NonDefaultData() map[string]interface{} {
+	root := c.rootConfig()
+	root.lock.RLock()
+	defer root.lock.RUnlock()
+
+	data := root.nonDefaultData()
+	if c.IsView() {
+		val, _ := findByKeys(data, strings.Split(c.prefix, string(c.opts.Delimiter)))
+		return toStringMap(val)
+	}
+	return data
+}
+
+// get config data without the default values, without lock.
+func (c *Config) nonDefaultData() map[string]interface{} {
+	if len(c.layers[LayerDefaults]) == 0 {
+		return c.data
+	}
+
+	data, err := c.mergeLayers(func(name string) bool {
+		return name != LayerDefaults
+	})
+	if err != nil {
+		c.addError(err)
+	}
+	return data
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_SetDefault(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.SetDefault("db.port", 3306))
+	is.NoError(c.SetDefaults(map[string]interface{}{
+		"name":    "def-name",
+		"db.host": "localhost",
+		"debug":   false,
+	}))
+	is.Error(c.SetDefault("", 1))
+
+	is.True(c.Exists("db.port"))
+	is.Equal(3306, c.Int("db.port"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Len(c.Defaults(), 3)
+	is.Equal(LayerDefaults, c.Origin("name"))
+
+	err := c.LoadStrings(JSON, `{"name": "app", "db": {"host": "127.0.0.1"}}`)
+	is.NoError(err)
+	is.Equal("app", c.String("name"))
+	is.Equal("127.0.0.1", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+
+	// set default after loaded, will not override
+	is.NoError(c.SetDefault("name", "new-def"))
+	is.Equal("app", c.String("name"))
+
+	db := struct {
+		Host string
+		Port int
+	}{}
+	is.NoError(c.Structure("db", &db))
+	is.Equal(3306, db.Port)
+
+	// view
+	is.NoError(c.Sub("db").SetDefault("user", "root"))
+	is.Equal("root", c.String("db.user"))
+
+	// dump
+	buf := new(bytes.Buffer)
+	_, err = c.DumpTo(buf, JSON)
+	is.NoError(err)
+	is.Contains(buf.String(), `"port":3306`)
+	is.Contains(buf.String(), `"debug":false`)
+
+	buf.Reset()
+	_, err = c.DumpWith(buf, JSON, ExcludeDefaults)
+	is.NoError(err)
+	is.Contains(buf.String(), `"name":"app"`)
+	is.NotContains(buf.String(), `"port"`)
+	is.NotContains(buf.String(), `"debug"`)
+
+	nd := c.NonDefaultData()
+	is.NotContains(nd, "debug")
+
+	// view dump
+	buf.Reset()
+	_, err = c.Sub("db").DumpWith(buf, JSON, ExcludeDefaults)
+	is.NoError(err)
+	is.Equal(`{"host":"127.0.0.1"}`+"\n", buf.String())
+}
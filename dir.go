@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirOptions options for load files in the dir. see LoadDir(), LoadGlob()
+type DirOptions struct {
+	// Recursive load the files in the sub dirs
+	Recursive bool
+	// MountByName mount each file data under the key derived from the file name.
+	//
+	// eg: "conf.d/db.yaml" => "db", "conf.d/cache/redis.yaml" => "cache.redis"(on Recursive=true)
+	MountByName bool
+}
+
+func newDirOptions(fns []func(*DirOptions)) *DirOptions {
+	opts := &DirOptions{}
+	for _, fn := range fns {
+		fn(opts)
+	}
+	return opts
+}
+
+// LoadDir load all config files in the dir
+func LoadDir(dir string, opts ...func(*DirOptions)) error { return dc.LoadDir(dir, opts...) }
+
+// LoadDir load all files with a registered format ext in the dir, in lexical order.
+// the files with unsupported format will be skipped, see SkippedFiles()
+//
+// Usage:
+//
+//	// /etc/app/conf.d/10-base.yaml, /etc/app/conf.d/20-db.toml ...
+//	err := c.LoadDir("/etc/app/conf.d")
+//
+//	// conf.d/db.yaml => "db.*", conf.d/cache/redis.yaml => "cache.redis.*"
+//	err = c.LoadDir("conf.d", func(o *config.DirOptions) {
+//		o.Recursive = true
+//		o.MountByName = true
+//	})
+func (c *Config) LoadDir(dir string, opts ...func(*DirOptions)) error {
+	dirOpts := newDirOptions(opts)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("config: the path %q is not a dir", dir)
+	}
+
+	var files []string
+	if dirOpts.Recursive {
+		err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		})
+	} else {
+		var list []os.FileInfo
+		list, err = ioutil.ReadDir(dir)
+		for _, fi := range list {
+			if !fi.IsDir() {
+				files = append(files, filepath.Join(dir, fi.Name()))
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	return c.loadDirFiles(dir, files, dirOpts)
+}
+
+// LoadGlob load all config files matched the pattern
+func LoadGlob(pattern string, opts ...func(*DirOptions)) error {
+	return dc.LoadGlob(pattern, opts...)
+}
+
+// LoadGlob load all files matched the pattern, in lexical order. the files
+// with unsupported format will be skipped, see SkippedFiles()
+//
+// The mounted key of the file is derived from the file name. see DirOptions.MountByName
+//
+// Usage:
+//
+//	err := c.LoadGlob("/etc/app/conf.d/*.yaml")
+func (c *Config) LoadGlob(pattern string, opts ...func(*DirOptions)) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("config: invalid glob pattern %q: %s", pattern, err.Error())
+	}
+
+	var list []string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			list = append(list, file)
+		}
+	}
+	return c.loadDirFiles("", list, newDirOptions(opts))
+}
+
+// SkippedFiles get the files skipped by LoadDir() and LoadGlob(), the format is not supported.
+func (c *Config) SkippedFiles() []string {
+	return c.rootConfig().skippedFiles
+}
+
+// load the files in lexical order, skip the files with unsupported format.
+func (c *Config) loadDirFiles(dir string, files []string, opts *DirOptions) error {
+	sort.Strings(files)
+
+	for _, file := range files {
+		ext := strings.Trim(filepath.Ext(file), ".")
+		if ext == "" || !c.HasDecoder(ext) {
+			root := c.rootConfig()
+			root.lock.Lock()
+			root.skippedFiles = append(root.skippedFiles, file)
+			root.lock.Unlock()
+			continue
+		}
+
+		var err error
+		if opts.MountByName {
+			err = c.loadMounted(file, c.fileKey(dir, file))
+		} else {
+			err = c.loadFile(file, false, "")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load the file data under the key, the key is relative to the view prefix.
+func (c *Config) loadMounted(file, key string) error {
+	if key = c.viewKey(key); key == "" {
+		return c.loadFile(file, false, "")
+	}
+	return c.handle(key, c.ctx).loadFile(file, false, "")
+}
+
+// get the key by file name, the sub dirs are joined by the delimiter.
+//
+// eg: "conf.d/db.yaml" => "db", "conf.d/cache/redis.yaml" => "cache.redis"
+func (c *Config) fileKey(dir, file string) string {
+	name := filepath.Base(file)
+	if dir != "" {
+		if rel, err := filepath.Rel(dir, file); err == nil {
+			name = rel
+		}
+	}
+
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.Replace(filepath.ToSlash(name), "/", string(c.opts.Delimiter), -1)
+}
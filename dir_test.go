@@ -0,0 +1,159 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_LoadDir(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"conf.d/10-base.json":     `{"name": "app", "db": {"host": "localhost", "port": 3306}}`,
+		"conf.d/20-db.yaml":       `{"db": {"host": "db.local"}}`,
+		"conf.d/README.md":        `# readme`,
+		"conf.d/noext":            `noext`,
+		"conf.d/cache/redis.json": `{"host": "127.0.0.1"}`,
+	})
+	confDir := filepath.Join(dir, "conf.d")
+
+	c := New("test")
+	c.AddDriver(yamlDriver)
+	is.NoError(c.LoadDir(confDir))
+	is.Equal("app", c.String("name"))
+	is.Equal("db.local", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.False(c.Exists("host"))
+	is.Equal([]string{
+		filepath.Join(confDir, "10-base.json"),
+		filepath.Join(confDir, "20-db.yaml"),
+	}, c.LoadedFiles())
+	is.Equal([]string{
+		filepath.Join(confDir, "README.md"),
+		filepath.Join(confDir, "noext"),
+	}, c.SkippedFiles())
+
+	// recursive and mount by name
+	c = New("test")
+	c.AddDriver(yamlDriver)
+	is.NoError(c.LoadDir(confDir, func(o *DirOptions) {
+		o.Recursive = true
+		o.MountByName = true
+	}))
+	is.Equal("app", c.String("10-base.name"))
+	is.Equal("db.local", c.String("20-db.db.host"))
+	is.Equal("127.0.0.1", c.String("cache.redis.host"))
+	is.False(c.Exists("name"))
+	is.Len(c.LoadedFiles(), 3)
+	is.Len(c.SkippedFiles(), 2)
+
+	// package level
+	is.NoError(LoadDir(filepath.Join(confDir, "cache"), func(o *DirOptions) {
+		o.MountByName = true
+	}))
+	is.Equal("127.0.0.1", String("redis.host"))
+	is.Empty(Default().SkippedFiles())
+	ClearAll()
+
+	// the mounted data can be written back
+	is.NoError(c.Set("cache.redis.host", "redis.local"))
+	is.NoError(c.SaveChanges())
+
+	c = New("test")
+	is.NoError(c.LoadFiles(filepath.Join(confDir, "cache/redis.json")))
+	is.Equal("redis.local", c.String("host"))
+
+	// errors
+	c = New("test")
+	is.Error(c.LoadDir(filepath.Join(dir, "not-exists")))
+	is.Error(c.LoadDir(filepath.Join(confDir, "10-base.json")))
+
+	writeTestFiles(t, dir, map[string]string{"bad.d/a.json": `{invalid`})
+	is.Error(c.LoadDir(filepath.Join(dir, "bad.d")))
+}
+
+func TestConfig_LoadDir_concurrent(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"conf.d/redis.json": `{"host": "127.0.0.1"}`,
+		"app.json":          `{"name": "app"}`,
+	})
+
+	c := New("test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			is.NoError(c.LoadDir(filepath.Join(dir, "conf.d"), func(o *DirOptions) {
+				o.MountByName = true
+			}))
+		}
+	}()
+
+	// the files are not mounted by the prefix of other callers
+	for i := 0; i < 20; i++ {
+		is.NoError(c.LoadFiles(filepath.Join(dir, "app.json")))
+	}
+	<-done
+
+	is.Equal("app", c.String("name"))
+	is.Equal("127.0.0.1", c.String("redis.host"))
+	is.Equal([]string{"name", "redis"}, topKeys(c.Data()))
+}
+
+func TestConfig_LoadGlob(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"conf.d/b.json":     `{"name": "b", "b": true}`,
+		"conf.d/a.json":     `{"name": "a", "a": true}`,
+		"conf.d/c.txt":      `c`,
+		"conf.d/sub/d.json": `{"d": true}`,
+	})
+
+	c := New("test")
+	is.NoError(c.LoadGlob(filepath.Join(dir, "conf.d/*")))
+	is.Equal("b", c.String("name"))
+	is.True(c.Bool("a"))
+	is.True(c.Bool("b"))
+	is.False(c.Exists("d"))
+	is.Equal([]string{filepath.Join(dir, "conf.d/c.txt")}, c.SkippedFiles())
+
+	c = New("test")
+	is.NoError(c.LoadGlob(filepath.Join(dir, "conf.d/*.json"), func(o *DirOptions) {
+		o.MountByName = true
+	}))
+	is.Equal("a", c.String("a.name"))
+	is.Equal("b", c.String("b.name"))
+	is.Empty(c.SkippedFiles())
+
+	// no matched
+	c = New("test")
+	is.NoError(c.LoadGlob(filepath.Join(dir, "not-exists/*.json")))
+	is.True(c.IsEmpty())
+
+	is.Error(c.LoadGlob("[invalid"))
+
+	// package level
+	is.NoError(LoadGlob(filepath.Join(dir, "conf.d/a.*")))
+	is.Equal("a", String("name"))
+	ClearAll()
+}
+
+func TestConfig_fileKey(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.Equal("db", c.fileKey("", "conf.d/db.yaml"))
+	is.Equal("db", c.fileKey("conf.d", "conf.d/db.yaml"))
+	is.Equal("cache.redis", c.fileKey("conf.d", "conf.d/cache/redis.yaml"))
+
+	c = NewWithOptions("test", Delimiter(':'))
+	is.Equal("cache:redis", c.fileKey("conf.d", "conf.d/cache/redis.yaml"))
+}
@@ -0,0 +1,458 @@
+/*
+Package dotenv is driver use .env format content as config source
+
+Supported syntax:
+
+	# comment line
+	NAME=app
+	export DEBUG=true           # the "export" prefix is allowed
+	TITLE="My App\n"            # double quoted, support escape chars and expand vars
+	RAW='${NOT_EXPAND}'         # single quoted, the value is literal
+	HOME_DIR=/home/${USER}      # expand the vars defined at earlier lines or in os ENV
+	LOG_DIR=${LOG_DIR:-/tmp}    # with default value
+	CERT="-----BEGIN-----
+	multi line value
+	-----END-----"
+*/
+package dotenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/config/v2"
+)
+
+// Options for the dotenv driver
+type Options struct {
+	// NestSep the separator for nested keys, default is not nested.
+	//
+	// eg: set as "__", "DB__HOST=localhost" will be decoded as {"DB": {"HOST": "localhost"}}
+	NestSep string
+	// LowerKey convert the keys to lower case on decode
+	LowerKey bool
+}
+
+// Decoder the .env content decoder
+var Decoder config.Decoder = NewDecoder(&Options{})
+
+// Encoder encode data to .env content
+var Encoder config.Encoder = NewEncoder(&Options{})
+
+// Driver for .env
+var Driver = config.NewDriver(config.DotEnv, Decoder, Encoder)
+
+// NewDriver create a .env driver with options
+//
+// Usage:
+//
+//	config.AddDriver(dotenv.NewDriver(func(o *dotenv.Options) {
+//		o.NestSep = "__"
+//		o.LowerKey = true
+//	}))
+//	err := config.LoadFiles(".env") // "DB__HOST=localhost" => config.String("db.host")
+func NewDriver(fns ...func(*Options)) *config.StdDriver {
+	opts := &Options{}
+	for _, fn := range fns {
+		fn(opts)
+	}
+	return config.NewDriver(config.DotEnv, NewDecoder(opts), NewEncoder(opts))
+}
+
+// NewDecoder create a .env content decoder with options
+func NewDecoder(opts *Options) config.Decoder {
+	return func(blob []byte, v interface{}) error {
+		data, err := Parse(blob, opts)
+		if err != nil {
+			return err
+		}
+
+		if ptr, ok := v.(*map[string]interface{}); ok {
+			*ptr = data
+			return nil
+		}
+
+		// decode to other types, eg: struct
+		bts, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(bts, v)
+	}
+}
+
+// NewEncoder create a .env content encoder with options. the nested keys
+// are joined by the NestSep, will use "_" if NestSep is empty.
+func NewEncoder(opts *Options) config.Encoder {
+	return func(v interface{}) ([]byte, error) {
+		var data map[string]interface{}
+		if mp, ok := v.(map[string]interface{}); ok {
+			data = mp
+		} else {
+			bts, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			if err = json.Unmarshal(bts, &data); err != nil {
+				return nil, err
+			}
+		}
+
+		sep := opts.NestSep
+		if sep == "" {
+			sep = "_"
+		}
+
+		flat := make(map[string]string)
+		if err := flatten("", data, sep, flat); err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(flat))
+		for key := range flat {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf := new(bytes.Buffer)
+		for _, key := range keys {
+			buf.WriteString(key)
+			buf.WriteByte('=')
+			buf.WriteString(flat[key])
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// Parse the .env content to map data
+func Parse(blob []byte, opts *Options) (map[string]interface{}, error) {
+	p := &parser{
+		src:  strings.Replace(string(blob), "\r\n", "\n", -1),
+		opts: opts,
+		vars: make(map[string]string),
+		data: make(map[string]interface{}),
+	}
+
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.data, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	opts *Options
+	// the parsed values, for expand vars
+	vars map[string]string
+	data map[string]interface{}
+}
+
+func (p *parser) parse() error {
+	for p.pos < len(p.src) {
+		p.line++
+		line := p.readLine()
+
+		str := strings.TrimSpace(line)
+		if str == "" || str[0] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(str, "export ") || strings.HasPrefix(str, "export\t") {
+			str = strings.TrimSpace(str[7:])
+		}
+
+		pos := strings.IndexByte(str, '=')
+		if pos < 1 {
+			return p.errorf("invalid line %q, the format should be KEY=VALUE", str)
+		}
+
+		key := strings.TrimSpace(str[:pos])
+		if strings.ContainsAny(key, " \t\"'") {
+			return p.errorf("invalid key %q", key)
+		}
+
+		val, err := p.parseValue(strings.TrimLeft(str[pos+1:], " \t"))
+		if err != nil {
+			return err
+		}
+
+		p.vars[key] = val
+		p.setValue(key, val)
+	}
+	return nil
+}
+
+// read the line at current position, and move to the next line.
+func (p *parser) readLine() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		line := p.src[p.pos:]
+		p.pos = len(p.src)
+		return line
+	}
+
+	line := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return line
+}
+
+// parse the value, the quoted value can be multi lines.
+func (p *parser) parseValue(str string) (string, error) {
+	if str == "" {
+		return "", nil
+	}
+
+	quote := str[0]
+	if quote != '"' && quote != '\'' {
+		// remove the inline comment
+		if pos := strings.Index(str, " #"); pos > -1 {
+			str = str[:pos]
+		} else if pos = strings.Index(str, "\t#"); pos > -1 {
+			str = str[:pos]
+		}
+		return p.expand(strings.TrimSpace(str), false), nil
+	}
+
+	// find the end quote, maybe in the next lines.
+	raw := str[1:]
+	for {
+		if end := findQuote(raw, quote); end > -1 {
+			tail := strings.TrimSpace(raw[end+1:])
+			if tail != "" && tail[0] != '#' {
+				return "", p.errorf("unexpected chars %q after the quoted value", tail)
+			}
+
+			raw = raw[:end]
+			break
+		}
+
+		if p.pos >= len(p.src) {
+			return "", p.errorf("the quoted value is not closed")
+		}
+
+		p.line++
+		raw += "\n" + p.readLine()
+	}
+
+	if quote == '\'' {
+		return raw, nil
+	}
+	return p.expand(raw, true), nil
+}
+
+// expand the vars and unescape chars(on escape=true) in the value
+func (p *parser) expand(str string, escape bool) string {
+	if !strings.ContainsAny(str, "$\\") {
+		return str
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if escape && c == '\\' && i+1 < len(str) {
+			i++
+			switch str[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '"', '\\', '$':
+				sb.WriteByte(str[i])
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(str[i])
+			}
+			continue
+		}
+
+		if c != '$' || i+1 >= len(str) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		// ${NAME} ${NAME:-default}
+		if str[i+1] == '{' {
+			end := strings.IndexByte(str[i:], '}')
+			if end < 0 {
+				sb.WriteByte(c)
+				continue
+			}
+
+			expr := str[i+2 : i+end]
+			name, def := expr, ""
+			if pos := strings.Index(expr, ":-"); pos > -1 {
+				name, def = expr[:pos], expr[pos+2:]
+			}
+
+			// not a var, eg: "${NAME|default}" for config.ParseEnv
+			if !isVarName(name) {
+				sb.WriteString(str[i : i+end+1])
+			} else if val := p.lookup(name); val != "" {
+				sb.WriteString(val)
+			} else {
+				sb.WriteString(def)
+			}
+
+			i += end
+			continue
+		}
+
+		// $NAME
+		end := i + 1
+		for end < len(str) && isVarChar(str[end], end == i+1) {
+			end++
+		}
+
+		if end == i+1 {
+			sb.WriteByte(c)
+			continue
+		}
+
+		sb.WriteString(p.lookup(str[i+1 : end]))
+		i = end - 1
+	}
+	return sb.String()
+}
+
+// lookup the var value from earlier lines, then os ENV
+func (p *parser) lookup(name string) string {
+	if val, ok := p.vars[name]; ok {
+		return val
+	}
+	return os.Getenv(name)
+}
+
+func (p *parser) setValue(key, val string) {
+	if p.opts.LowerKey {
+		key = strings.ToLower(key)
+	}
+
+	if p.opts.NestSep == "" || !strings.Contains(key, p.opts.NestSep) {
+		p.data[key] = val
+		return
+	}
+
+	mp := p.data
+	keys := strings.Split(key, p.opts.NestSep)
+	last := len(keys) - 1
+	for _, k := range keys[:last] {
+		sub, ok := mp[k].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			mp[k] = sub
+		}
+		mp = sub
+	}
+	mp[keys[last]] = val
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("dotenv: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// find the end quote position, skip the escaped quote in double quoted value.
+func findQuote(str string, quote byte) int {
+	for i := 0; i < len(str); i++ {
+		if quote == '"' && str[i] == '\\' {
+			i++
+			continue
+		}
+
+		if str[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		if !isVarChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarChar(c byte, first bool) bool {
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+// flatten the nested data to KEY=VALUE pairs
+func flatten(prefix string, data map[string]interface{}, sep string, out map[string]string) error {
+	for key, val := range data {
+		if prefix != "" {
+			key = prefix + sep + key
+		}
+
+		switch typVal := val.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, typVal, sep, out); err != nil {
+				return err
+			}
+		case map[interface{}]interface{}:
+			mp := make(map[string]interface{}, len(typVal))
+			for k, v := range typVal {
+				mp[fmt.Sprint(k)] = v
+			}
+			if err := flatten(key, mp, sep, out); err != nil {
+				return err
+			}
+		case nil:
+			out[key] = ""
+		case string:
+			out[key] = quoteValue(typVal)
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			out[key] = fmt.Sprint(typVal)
+		case float32:
+			// not use the exponent format. eg: 1e+06
+			out[key] = strconv.FormatFloat(float64(typVal), 'f', -1, 32)
+		case float64:
+			out[key] = strconv.FormatFloat(typVal, 'f', -1, 64)
+		default:
+			// eg: slice, encode as JSON string
+			bts, err := json.Marshal(typVal)
+			if err != nil {
+				return errors.New("dotenv: cannot encode the value of the key " + key + ": " + err.Error())
+			}
+			out[key] = quoteValue(string(bts))
+		}
+	}
+	return nil
+}
+
+// quote the string value if it has special chars. the "$" is escaped for not expand on decode.
+func quoteValue(str string) string {
+	if str != "" && !strings.ContainsAny(str, " \t\n\r#\"'\\$=") {
+		return str
+	}
+	return `"` + quoteReplacer.Replace(str) + `"`
+}
+
+var quoteReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	`"`, `\"`,
+	"$", "\\$",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\t", "\\t",
+)
@@ -0,0 +1,200 @@
+package dotenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/config/v2"
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var envStr = `
+# comment line
+NAME=app
+export DEBUG=true # inline comment
+  PORT = 8080
+EMPTY=
+TITLE="My App\t\"v1\"\n"
+RAW='${NAME} \n raw'
+HOME_DIR=/home/${NAME}/$NAME
+LOG_DIR=${NOT_EXIST_VAR:-/tmp}/logs
+PATH_VAL=${TEST_DOTENV_OS_VAR}
+KEEP="${NAME|default} \$NAME"
+URL=http://abc.com/#anchor
+CERT="-----BEGIN-----
+line1
+line2
+-----END-----" # the cert
+JSON='{"a": 1,
+"b": 2}'
+`
+
+func TestDriver(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal("env", Driver.Name())
+	is.Equal(config.DotEnv, Driver.Name())
+
+	c := config.NewEmpty("test")
+	c.AddDriver(Driver)
+	is.True(c.HasDecoder(config.DotEnv))
+	is.True(c.HasEncoder("dotenv"))
+
+	testutil.MockEnvValue("TEST_DOTENV_OS_VAR", "/usr/bin", func(_ string) {
+		is.NoError(c.LoadStrings(config.DotEnv, envStr))
+	})
+
+	is.Equal("app", c.String("NAME"))
+	is.True(c.Bool("DEBUG"))
+	is.Equal(8080, c.Int("PORT"))
+	is.Equal("", c.String("EMPTY"))
+	is.True(c.Exists("EMPTY"))
+	is.Equal("My App\t\"v1\"\n", c.String("TITLE"))
+	is.Equal(`${NAME} \n raw`, c.String("RAW"))
+	is.Equal("/home/app/app", c.String("HOME_DIR"))
+	is.Equal("/tmp/logs", c.String("LOG_DIR"))
+	is.Equal("/usr/bin", c.String("PATH_VAL"))
+	is.Equal("${NAME|default} $NAME", c.String("KEEP"))
+	is.Equal("http://abc.com/#anchor", c.String("URL"))
+	is.Equal("-----BEGIN-----\nline1\nline2\n-----END-----", c.String("CERT"))
+	is.Equal("{\"a\": 1,\n\"b\": 2}", c.String("JSON"))
+}
+
+func TestParse_error(t *testing.T) {
+	is := assert.New(t)
+
+	tests := map[string]string{
+		"no-equal":     "NAME=app\ninvalid line",
+		"empty-key":    "=value",
+		"invalid-key":  `"NAME"=value`,
+		"not-closed":   "NAME=app\nCERT=\"line1\nline2",
+		"after-quoted": `NAME="app" other`,
+	}
+
+	for name, str := range tests {
+		_, err := Parse([]byte(str), &Options{})
+		is.Error(err, name)
+	}
+
+	_, err := Parse([]byte("NAME=app\nCERT=\"line1\nline2"), &Options{})
+	is.EqualError(err, "dotenv: line 3: the quoted value is not closed")
+
+	c := config.NewEmpty("test")
+	c.AddDriver(Driver)
+	is.Error(c.LoadStrings(config.DotEnv, "invalid"))
+}
+
+func TestNewDriver(t *testing.T) {
+	is := assert.New(t)
+
+	c := config.NewEmpty("test")
+	c.AddDriver(NewDriver(func(o *Options) {
+		o.NestSep = "__"
+		o.LowerKey = true
+	}))
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".env")
+	is.NoError(ioutil.WriteFile(file, []byte("APP_NAME=demo\nDB__HOST=localhost\nDB__PORT=3306\nDB__OPTS__SSL=true\n"), 0600))
+
+	is.NoError(c.LoadFiles(file))
+	is.Equal("demo", c.String("app_name"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.True(c.Bool("db.opts.ssl"))
+
+	// dump to .env file
+	is.NoError(c.Set("db.host", "127.0.0.1"))
+	is.NoError(c.Set("tags", []string{"a", "b"}))
+	is.NoError(c.Set("title", "My App $1"))
+
+	out := filepath.Join(dir, "out.env")
+	is.NoError(c.DumpToFile(out, ""))
+	bts, err := ioutil.ReadFile(out)
+	is.NoError(err)
+	is.Equal(`app_name=demo
+db__host=127.0.0.1
+db__opts__ssl=true
+db__port=3306
+tags="[\"a\",\"b\"]"
+title="My App \$1"
+`, string(bts))
+
+	// round trip
+	c2 := config.NewEmpty("test")
+	c2.AddDriver(NewDriver(func(o *Options) {
+		o.NestSep = "__"
+	}))
+	is.NoError(c2.LoadFiles(out))
+	is.Equal("127.0.0.1", c2.String("db.host"))
+	is.Equal("My App $1", c2.String("title"))
+	is.Equal(`["a","b"]`, c2.String("tags"))
+}
+
+func TestEncoder(t *testing.T) {
+	is := assert.New(t)
+
+	bts, err := Encoder(map[string]interface{}{
+		"NAME":  "app",
+		"EMPTY": "",
+		"NIL":   nil,
+		"MULTI": "line1\nline2\t\"quoted\" \\",
+		"DB": map[interface{}]interface{}{
+			"HOST": "localhost",
+			"PORT": 3306,
+		},
+		"RATE": 0.5,
+		"SIZE": float64(1000000),
+		"MIN":  float32(0.000001),
+	})
+	is.NoError(err)
+	is.Equal(`DB_HOST=localhost
+DB_PORT=3306
+EMPTY=""
+MIN=0.000001
+MULTI="line1\nline2\t\"quoted\" \\"
+NAME=app
+NIL=
+RATE=0.5
+SIZE=1000000
+`, string(bts))
+
+	data, err := Parse(bts, &Options{})
+	is.NoError(err)
+	is.Equal("line1\nline2\t\"quoted\" \\", data["MULTI"])
+	is.Equal("", data["EMPTY"])
+
+	// struct
+	bts, err = Encoder(struct{ Name string }{Name: "app"})
+	is.NoError(err)
+	is.Equal("Name=app\n", string(bts))
+
+	_, err = Encoder("invalid")
+	is.Error(err)
+
+	// decode to struct
+	st := struct{ NAME string }{}
+	is.NoError(Decoder([]byte("NAME=app"), &st))
+	is.Equal("app", st.NAME)
+	is.Error(Decoder([]byte("NAME"), &st))
+}
+
+func TestLoadFiles(t *testing.T) {
+	is := assert.New(t)
+
+	config.AddDriver(Driver)
+	defer config.ClearAll()
+
+	wd, err := os.Getwd()
+	is.NoError(err)
+
+	file := filepath.Join(t.TempDir(), ".env")
+	is.NoError(ioutil.WriteFile(file, []byte("NAME=app\nWORK_DIR="+wd+"\n"), 0644))
+
+	is.NoError(config.LoadFiles(file))
+	is.Equal("app", config.String("NAME"))
+	is.Equal(wd, config.String("WORK_DIR"))
+}
@@ -2,7 +2,11 @@ package config
 
 // default json driver(encoder/decoder)
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/gookit/goutil/jsonutil"
 )
@@ -21,11 +25,31 @@ type Decoder func(blob []byte, v interface{}) (err error)
 // Encoder for decode yml,json,toml format content
 type Encoder func(v interface{}) (out []byte, err error)
 
+// DocsDecoder for decode multi documents content. eg: "---" separated yaml, ndjson
+type DocsDecoder func(blob []byte) (docs []map[string]interface{}, err error)
+
+// DocsDriver the driver support decode multi documents content.
+// on load content by the driver, all documents will be merged in order.
+type DocsDriver interface {
+	Driver
+	GetDocsDecoder() DocsDecoder
+}
+
+// FileDriver the driver keeps state for each file, the file path is given on decode
+// the file content and encode data for the file. eg: the round-trip yaml driver
+type FileDriver interface {
+	Driver
+	DecodeFile(file string, blob []byte, v interface{}) error
+	EncodeFile(file string, v interface{}) ([]byte, error)
+}
+
 // StdDriver struct
 type StdDriver struct {
 	name    string
 	decoder Decoder
 	encoder Encoder
+	// decoder for multi documents
+	docsDecoder DocsDecoder
 }
 
 // NewDriver new std driver instance.
@@ -33,6 +57,11 @@ func NewDriver(name string, dec Decoder, enc Encoder) *StdDriver {
 	return &StdDriver{name: name, decoder: dec, encoder: enc}
 }
 
+// NewDocsDriver new std driver instance, with the multi documents decoder.
+func NewDocsDriver(name string, dec Decoder, enc Encoder, docsDec DocsDecoder) *StdDriver {
+	return &StdDriver{name: name, decoder: dec, encoder: enc, docsDecoder: docsDec}
+}
+
 // Name of driver
 func (d *StdDriver) Name() string {
 	return d.name
@@ -58,6 +87,11 @@ func (d *StdDriver) GetEncoder() Encoder {
 	return d.encoder
 }
 
+// GetDocsDecoder of driver, will return nil on not support multi documents.
+func (d *StdDriver) GetDocsDecoder() DocsDecoder {
+	return d.docsDecoder
+}
+
 var (
 	// JSONAllowComments support write comments on json file.
 	JSONAllowComments = true
@@ -116,3 +150,59 @@ func (d *jsonDriver) GetDecoder() Decoder {
 func (d *jsonDriver) GetEncoder() Encoder {
 	return JSONEncoder
 }
+
+// NDJSONDocsDecoder decode the newline delimited JSON, each non-empty line is a document.
+var NDJSONDocsDecoder DocsDecoder = func(blob []byte) (docs []map[string]interface{}, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(blob))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(blob)+1)
+
+	for line := 1; scanner.Scan(); line++ {
+		bts := bytes.TrimSpace(scanner.Bytes())
+		if len(bts) == 0 {
+			continue
+		}
+
+		var doc map[string]interface{}
+		if err = json.Unmarshal(bts, &doc); err != nil {
+			return nil, fmt.Errorf("ndjson: decode line %d error: %v", line, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, scanner.Err()
+}
+
+// NDJSONDecoder for ndjson decode. if v is *map[string]interface{}, will merge all documents to it.
+// otherwise, only decode the first document.
+var NDJSONDecoder Decoder = func(blob []byte, v interface{}) (err error) {
+	if mp, ok := v.(*map[string]interface{}); ok {
+		docs, err := NDJSONDocsDecoder(blob)
+		if err != nil {
+			return err
+		}
+
+		if *mp == nil {
+			*mp = make(map[string]interface{})
+		}
+
+		m := &merger{opts: &MergeOptions{}, sep: string(defaultDelimiter)}
+		for _, doc := range docs {
+			if err = m.mergeMap(*mp, doc, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, line := range bytes.Split(blob, []byte{'\n'}) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return json.Unmarshal(line, v)
+		}
+	}
+	return errors.New("ndjson: not found any document")
+}
+
+// NDJSONEncoder for ndjson encode, the data will be encoded as one line.
+var NDJSONEncoder Encoder = json.Marshal
+
+// NDJSONDriver instance fot ndjson(json lines). the file ext ".jsonl" is also supported.
+var NDJSONDriver = NewDocsDriver(NDJSON, NDJSONDecoder, NDJSONEncoder, NDJSONDocsDecoder)
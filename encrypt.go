@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// the encrypted value format: ENC[AES256_GCM,data:<base64>,iv:<base64>]
+const (
+	encPrefix = "ENC["
+	encCipher = "AES256_GCM"
+)
+
+var errInvalidEncValue = errors.New("invalid encrypted value format")
+
+// KeyProvider provide the key for encrypt and decrypt config values.
+// the key length must be 32 bytes for AES-256.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyProviderFunc func as a KeyProvider
+type KeyProviderFunc func() ([]byte, error)
+
+// Key of the provider
+func (fn KeyProviderFunc) Key() ([]byte, error) {
+	return fn()
+}
+
+// StaticKey create a KeyProvider with fixed key
+func StaticKey(key []byte) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		return key, nil
+	})
+}
+
+// KeyFileProvider create a KeyProvider, read key from local file.
+// the file content can be raw 32 bytes or base64 encoded.
+func KeyFileProvider(path string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		bts, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return decodeKey(bytes.TrimSpace(bts))
+	})
+}
+
+// EnvKeyProvider create a KeyProvider, read key from the ENV var.
+// the value can be raw 32 bytes or base64 encoded.
+func EnvKeyProvider(name string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		val := strings.TrimSpace(os.Getenv(name))
+		if val == "" {
+			return nil, fmt.Errorf("the key ENV var %q is not set", name)
+		}
+		return decodeKey([]byte(val))
+	})
+}
+
+func decodeKey(bts []byte) ([]byte, error) {
+	if len(bts) == 32 {
+		return bts, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(bts))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("the key must be 32 bytes or base64 encoded 32 bytes")
+	}
+	return key, nil
+}
+
+// WithKeyProvider set the KeyProvider for decrypt the encrypted values
+func WithKeyProvider(kp KeyProvider) func(*Options) {
+	return func(opts *Options) {
+		opts.KeyProvider = kp
+	}
+}
+
+// SetKeyProvider set the KeyProvider for encrypt and decrypt values.
+//
+// The encrypted values like "ENC[AES256_GCM,data:...,iv:...]" will be decrypted
+// transparently on read by GetValue, typed getters and Structure. But will not
+// decrypt on dump data by ToJSON, DumpTo, unless use the dump option DecryptValues.
+//
+// The key is read from the provider once and cached, set the provider again for reload the key.
+func (c *Config) SetKeyProvider(kp KeyProvider) {
+	if c.root != nil {
+		c.root.SetKeyProvider(kp)
+		return
+	}
+
+	c.encLock.Lock()
+	c.opts.KeyProvider = kp
+	c.encKey = nil
+	c.encLock.Unlock()
+}
+
+// IsEncrypted check the value is an encrypted value. eg: "ENC[AES256_GCM,data:...,iv:...]"
+func IsEncrypted(val string) bool {
+	return strings.HasPrefix(val, encPrefix) && strings.HasSuffix(val, "]")
+}
+
+// EncryptKeys set the key paths, the values will be encrypted on dump data.
+//
+// Usage:
+//
+//	c.SetKeyProvider(config.EnvKeyProvider("APP_CONFIG_KEY"))
+//	c.EncryptKeys("db.password", "api.token")
+//	err := c.DumpToFile("app.json", config.JSON)
+func (c *Config) EncryptKeys(keys ...string) {
+	if c.root != nil {
+		full := make([]string, 0, len(keys))
+		for _, key := range keys {
+			full = append(full, c.viewKey(key))
+		}
+		c.root.EncryptKeys(full...)
+		return
+	}
+
+	c.encryptKeys = append(c.encryptKeys, keys...)
+}
+
+// EncryptValue encrypt a plaintext value by the KeyProvider
+func (c *Config) EncryptValue(plain string) (string, error) {
+	key, err := c.encryptKey()
+	if err != nil {
+		return "", err
+	}
+	return EncryptString(key, plain)
+}
+
+// DecryptValue decrypt an encrypted value by the KeyProvider
+func (c *Config) DecryptValue(encrypted string) (string, error) {
+	key, err := c.encryptKey()
+	if err != nil {
+		return "", err
+	}
+	return DecryptString(key, encrypted)
+}
+
+// get the key from the KeyProvider, the key is cached on read success.
+func (c *Config) encryptKey() ([]byte, error) {
+	if c.root != nil {
+		return c.root.encryptKey()
+	}
+
+	c.encLock.Lock()
+	defer c.encLock.Unlock()
+
+	if c.encKey != nil {
+		return c.encKey, nil
+	}
+
+	if c.opts.KeyProvider == nil {
+		return nil, errors.New("config: the KeyProvider is not set")
+	}
+
+	key, err := c.opts.KeyProvider.Key()
+	if err == nil {
+		c.encKey = key
+	}
+	return key, err
+}
+
+// encrypt the values of the EncryptKeys in the data, will return new data.
+func (c *Config) encryptData(data map[string]interface{}) (map[string]interface{}, error) {
+	sep := c.opts.Delimiter
+	data = deepCopyMap(data)
+
+	for _, key := range c.encryptKeys {
+		val, ok := findByKeys(data, strings.Split(key, string(sep)))
+		if !ok || val == nil {
+			continue
+		}
+
+		str, err := strutil.AnyToString(val, false)
+		if err != nil || IsEncrypted(str) {
+			continue
+		}
+
+		if str, err = c.EncryptValue(str); err != nil {
+			return nil, fmt.Errorf("config: encrypt the value of the key %q error: %v", key, err)
+		}
+
+		if err = setValue(data, key, str, sep, true); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// EncryptString encrypt the plaintext by AES-256-GCM, returns the encrypted value.
+// eg: "ENC[AES256_GCM,data:...,iv:...]"
+func EncryptString(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	data := gcm.Seal(nil, iv, []byte(plain), nil)
+	return fmt.Sprintf(
+		"%s%s,data:%s,iv:%s]",
+		encPrefix,
+		encCipher,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+	), nil
+}
+
+// DecryptString decrypt the encrypted value by AES-256-GCM, returns the plaintext.
+func DecryptString(key []byte, encrypted string) (string, error) {
+	if !IsEncrypted(encrypted) {
+		return "", errInvalidEncValue
+	}
+
+	nodes := strings.Split(encrypted[len(encPrefix):len(encrypted)-1], ",")
+	if nodes[0] != encCipher {
+		return "", fmt.Errorf("not supported cipher %q", nodes[0])
+	}
+
+	var data, iv, tag []byte
+	for _, node := range nodes[1:] {
+		kv := strings.SplitN(node, ":", 2)
+		if len(kv) != 2 {
+			return "", errInvalidEncValue
+		}
+
+		bts, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return "", errInvalidEncValue
+		}
+
+		switch kv[0] {
+		case "data":
+			data = bts
+		case "iv":
+			iv = bts
+		case "tag": // the tag can be separated from data
+			tag = bts
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(iv) != gcm.NonceSize() || len(data) == 0 {
+		return "", errInvalidEncValue
+	}
+
+	plain, err := gcm.Open(nil, iv, append(data, tag...), nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("the key length must be 32 bytes for AES-256")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
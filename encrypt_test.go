@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var testEncKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptString(t *testing.T) {
+	is := assert.New(t)
+
+	enc, err := EncryptString(testEncKey, "s3cret")
+	is.NoError(err)
+	is.True(IsEncrypted(enc))
+	is.Contains(enc, "ENC[AES256_GCM,data:")
+
+	plain, err := DecryptString(testEncKey, enc)
+	is.NoError(err)
+	is.Equal("s3cret", plain)
+
+	_, err = DecryptString([]byte("fedcba9876543210fedcba9876543210"), enc)
+	is.Error(err)
+	_, err = DecryptString(testEncKey, "ENC[AES256_GCM,data:invalid]")
+	is.Error(err)
+	_, err = DecryptString(testEncKey, "ENC[OTHER,data:abc]")
+	is.Error(err)
+	_, err = DecryptString(testEncKey, "not-encrypted")
+	is.Error(err)
+	_, err = EncryptString([]byte("short"), "s3cret")
+	is.Error(err)
+}
+
+func TestKeyProviders(t *testing.T) {
+	is := assert.New(t)
+
+	keyFile := filepath.Join(t.TempDir(), "config.key")
+	is.NoError(ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(testEncKey)+"\n"), 0600))
+
+	key, err := KeyFileProvider(keyFile).Key()
+	is.NoError(err)
+	is.Equal(testEncKey, key)
+
+	_, err = KeyFileProvider("not-exist.key").Key()
+	is.Error(err)
+
+	testutil.MockEnvValue("APP_CONFIG_KEY", string(testEncKey), func(_ string) {
+		key, err := EnvKeyProvider("APP_CONFIG_KEY").Key()
+		is.NoError(err)
+		is.Equal(testEncKey, key)
+	})
+
+	_, err = EnvKeyProvider("APP_NOT_EXIST_KEY").Key()
+	is.Error(err)
+
+	testutil.MockEnvValue("APP_CONFIG_KEY", "invalid", func(_ string) {
+		_, err := EnvKeyProvider("APP_CONFIG_KEY").Key()
+		is.Error(err)
+	})
+}
+
+func TestConfig_encryptedValues(t *testing.T) {
+	is := assert.New(t)
+
+	enc, err := EncryptString(testEncKey, "s3cret")
+	is.NoError(err)
+
+	c := NewWithOptions("test", WithKeyProvider(StaticKey(testEncKey)))
+	err = c.LoadData(map[string]interface{}{
+		"db": map[string]interface{}{
+			"user":     "root",
+			"password": enc,
+		},
+	})
+	is.NoError(err)
+
+	// decrypt on read
+	is.Equal("s3cret", c.String("db.password"))
+	st := struct{ User, Password string }{}
+	is.NoError(c.Structure("db", &st))
+	is.Equal("s3cret", st.Password)
+	is.Equal("s3cret", c.StringMap("db")["password"])
+
+	// not decrypt on dump
+	is.NotContains(c.ToJSON(), "s3cret")
+	is.Contains(c.ToJSON(), enc)
+	is.Equal(enc, c.Data()["db"].(map[string]interface{})["password"])
+	is.NotContains(c.Sub("db").ToJSON(), "s3cret")
+
+	buf := new(bytes.Buffer)
+	_, err = c.DumpWith(buf, JSON, DecryptValues)
+	is.NoError(err)
+	is.Contains(buf.String(), `"password":"******"`)
+
+	buf.Reset()
+	_, err = c.DumpWith(buf, JSON, DecryptValues, RevealSecrets)
+	is.NoError(err)
+	is.Contains(buf.String(), `"password":"s3cret"`)
+
+	// encrypt keys on dump
+	is.NoError(c.Set("db.user", "admin"))
+	c.EncryptKeys("db.user", "db.password", "not.exist")
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	is.NoError(c.DumpToFile(file, JSON))
+
+	c2 := NewWithOptions("test", WithKeyProvider(StaticKey(testEncKey)))
+	is.NoError(c2.LoadFiles(file))
+	is.True(IsEncrypted(c2.Data()["db"].(map[string]interface{})["user"].(string)))
+	is.Equal(enc, c2.Data()["db"].(map[string]interface{})["password"])
+	is.Equal("admin", c2.String("db.user"))
+
+	// decrypt error
+	c2.SetKeyProvider(StaticKey([]byte("fedcba9876543210fedcba9876543210")))
+	is.Equal(enc, c2.String("db.password"))
+	is.Error(c2.Error())
+
+	// without key provider
+	c3 := New("test")
+	is.NoError(c3.LoadFiles(file))
+	is.Equal(enc, c3.String("db.password"))
+	_, err = c3.EncryptValue("abc")
+	is.Error(err)
+}
+
+func TestConfig_encryptKey_cached(t *testing.T) {
+	is := assert.New(t)
+
+	enc, err := EncryptString(testEncKey, "s3cret")
+	is.NoError(err)
+
+	var calls int
+	c := NewWithOptions("test", WithKeyProvider(KeyProviderFunc(func() ([]byte, error) {
+		calls++
+		return testEncKey, nil
+	})))
+	is.NoError(c.Set("password", enc))
+
+	is.Equal("s3cret", c.String("password"))
+	is.Equal("s3cret", c.String("password"))
+	is.Equal(1, calls)
+
+	// reload the key by set provider again
+	c.SetKeyProvider(KeyProviderFunc(func() ([]byte, error) {
+		calls++
+		return nil, errors.New("key not found")
+	}))
+	is.Equal(enc, c.String("password"))
+	is.Equal(enc, c.String("password"))
+	is.Equal(3, calls) // the error result is not cached
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvOptions options for load ENV by prefix. see LoadEnvPrefix()
+type EnvOptions struct {
+	// NestSep the separator for nested keys in the ENV name. default is "__"
+	//
+	// eg: "APP_DB__HOST" => "db.host"
+	NestSep string
+	// WordSep the single "_" in the ENV name will be replaced to it. default is keep "_"
+	//
+	// eg: set as ".", "APP_DB_HOST" => "db.host". set as "-", "APP_MAX_CONN" => "max-conn"
+	WordSep string
+	// KeepCase keep the case of the ENV name, default will convert to lower case.
+	KeepCase bool
+	// ParseList parse the JSON array or separated string to list. eg: `["a", "b"]`, "a,b"
+	//
+	// only parse on the exists value is a list or the key is not exists, eg: "a,b" is kept for string value.
+	ParseList bool
+	// ListSep the separator for parse string to list. default is ","
+	ListSep string
+}
+
+func newEnvOptions(fns []func(*EnvOptions)) *EnvOptions {
+	opts := &EnvOptions{NestSep: "__", WordSep: "_", ListSep: ","}
+	for _, fn := range fns {
+		fn(opts)
+	}
+	return opts
+}
+
+// LoadEnvPrefix load the ENV vars which name has the prefix
+func LoadEnvPrefix(prefix string, opts ...func(*EnvOptions)) error {
+	return dc.LoadEnvPrefix(prefix, opts...)
+}
+
+// LoadEnvPrefix load the ENV vars which name has the prefix, the prefix will be
+// removed, and the "__" in the name will be mapped to nested key path.
+//
+// The value will be converted to the type of the exists value in the config. eg: on
+// the "db.port" value is int, "APP_DB__PORT=3306" will be loaded as int 3306.
+//
+// Usage:
+//
+//	// APP_DB__HOST=localhost APP_DB__PORT=3306 APP_LOG_LEVEL=debug APP_TAGS=a,b
+//	err := c.LoadEnvPrefix("APP_", func(o *config.EnvOptions) {
+//		o.ParseList = true
+//	})
+//
+//	c.String("db.host") // "localhost"
+//	c.String("log_level") // "debug"
+//	c.Strings("tags") // []string{"a", "b"}
+func (c *Config) LoadEnvPrefix(prefix string, opts ...func(*EnvOptions)) (err error) {
+	envOpts := newEnvOptions(opts)
+
+	envs := make(map[string]string)
+	for _, env := range os.Environ() {
+		name, val := env, ""
+		if pos := strings.IndexByte(env, '='); pos > -1 {
+			name, val = env[:pos], env[pos+1:]
+		}
+
+		if strings.HasPrefix(name, prefix) {
+			if key := c.envToKey(name[len(prefix):], envOpts); key != "" {
+				envs[key] = val
+			}
+		}
+	}
+
+	if len(envs) == 0 {
+		return nil
+	}
+
+	// set the values in order, for stable result on the keys are conflict.
+	keys := make([]string, 0, len(envs))
+	for key := range envs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sep := c.opts.Delimiter
+	layer := c.layerFor(LayerEnv)
+	data := make(map[string]interface{}, len(envs))
+
+	root := c.rootConfig()
+	root.lock.Lock()
+	// convert the value like the value below the layer, not the value from a previous ENV load.
+	below, err := root.dataBelow(layer)
+	if err != nil {
+		root.lock.Unlock()
+		return
+	}
+
+	for _, key := range keys {
+		exist, _ := findByKeys(below, strings.Split(c.viewKey(key), string(sep)))
+		if err = setValue(data, key, parseEnvValue(envs[key], exist, envOpts), sep, true); err != nil {
+			root.lock.Unlock()
+			return
+		}
+	}
+	// on the config is a view, the data is mounted under the view prefix.
+	err = root.mergeLayer(layer, mountDocs(c.prefix, []map[string]interface{}{data}, sep)[0], c.mergeOptions())
+	root.lock.Unlock()
+
+	if err == nil {
+		c.notify(OnLoadData, layer, topKeys(data)...)
+	}
+	return
+}
+
+// convert the ENV name(without prefix) to config key path.
+// eg: "DB__MAX_CONN" => "db.max_conn"
+func (c *Config) envToKey(name string, opts *EnvOptions) string {
+	if !opts.KeepCase {
+		name = strings.ToLower(name)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(name, opts.NestSep) {
+		if opts.WordSep != "_" {
+			part = strings.Replace(part, "_", opts.WordSep, -1)
+		}
+
+		if part = formatKey(part, string(c.opts.Delimiter)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, string(c.opts.Delimiter))
+}
+
+// parse the ENV value, will convert to the type of the exists value.
+func parseEnvValue(val string, exist interface{}, opts *EnvOptions) interface{} {
+	if !opts.ParseList {
+		return convertLike(val, exist)
+	}
+
+	// only parse list on the exists value is list or the key is not exists.
+	// eg: keep "a,b" as string for the exists string value.
+	rv := reflect.ValueOf(exist)
+	if exist != nil && rv.Kind() != reflect.Slice {
+		return convertLike(val, exist)
+	}
+
+	// use the first element type of the exists list
+	var like interface{}
+	if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+		like = rv.Index(0).Interface()
+	}
+
+	var list []interface{}
+	str := strings.TrimSpace(val)
+	if strings.HasPrefix(str, "[") && json.Unmarshal([]byte(str), &list) == nil {
+		for i, item := range list {
+			if s, ok := item.(string); ok {
+				list[i] = convertLike(s, like)
+			}
+		}
+		return list
+	}
+
+	if opts.ListSep == "" || !strings.Contains(val, opts.ListSep) {
+		return convertLike(val, exist)
+	}
+
+	for _, item := range strings.Split(val, opts.ListSep) {
+		list = append(list, convertLike(strings.TrimSpace(item), like))
+	}
+	return list
+}
+
+// convert the string to the type of the like value. will return the string on convert fail.
+func convertLike(str string, like interface{}) interface{} {
+	if like == nil {
+		return str
+	}
+
+	rv := reflect.ValueOf(like)
+	switch rv.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil && !rv.OverflowInt(i) {
+			return reflect.ValueOf(i).Convert(rv.Type()).Interface()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(str, 10, 64); err == nil && !rv.OverflowUint(u) {
+			return reflect.ValueOf(u).Convert(rv.Type()).Interface()
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return reflect.ValueOf(f).Convert(rv.Type()).Interface()
+		}
+	}
+	return str
+}
+
+// AutomaticEnv enable find value from ENV on read config
+func AutomaticEnv(prefix string) { dc.AutomaticEnv(prefix) }
+
+// AutomaticEnv enable find value from ENV on read config. the ENV name is
+// prefix + "_" + upper key path, the delimiter and "-" are replaced to "_".
+// eg: prefix "APP", the key "db.host" => "APP_DB_HOST"
+//
+// The ENV value has the priority of LayerEnv, is used by GetValue, Exists, the typed
+// getters and Structure. the values of flags and Set() still override it.
+// Origin() will return LayerEnv for the key.
+//
+// Usage:
+//
+//	c.AutomaticEnv("APP")
+//	host := c.String("db.host") // will use ENV APP_DB_HOST if it exists
+func (c *Config) AutomaticEnv(prefix string) {
+	if c.root != nil {
+		c.root.AutomaticEnv(prefix)
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.autoEnv = true
+	c.envPrefix = strings.TrimSuffix(prefix, "_")
+}
+
+// BindEnv bind ENV names to the key
+func BindEnv(key string, names ...string) error { return dc.BindEnv(key, names...) }
+
+// BindEnv bind ENV names to the key, the first exists ENV will be used on read the key.
+// if the names is empty, will use the ENV name same as AutomaticEnv().
+//
+// Usage:
+//
+//	err := c.BindEnv("db.host", "DATABASE_HOST", "PGHOST")
+func (c *Config) BindEnv(key string, names ...string) error {
+	if c.root != nil {
+		return c.root.BindEnv(c.viewKey(key), names...)
+	}
+
+	if key = formatKey(key, string(c.opts.Delimiter)); key == "" {
+		return errKeyIsEmpty
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.envBinds == nil {
+		c.envBinds = make(map[string][]string)
+	}
+	c.envBinds[key] = names
+	return nil
+}
+
+// check has any ENV binding for read values
+func (c *Config) hasEnvBinding() bool {
+	return c.autoEnv || len(c.envBinds) > 0
+}
+
+// get the ENV name of the key by AutomaticEnv()
+func (c *Config) envName(key string) string {
+	name := strings.NewReplacer(string(c.opts.Delimiter), "_", "-", "_").Replace(key)
+	if c.envPrefix == "" {
+		return strings.ToUpper(name)
+	}
+	return c.envPrefix + "_" + strings.ToUpper(name)
+}
+
+// find the ENV value of the key at the priority of LayerEnv, skip it if the key is set in a higher layer.
+func (c *Config) layerEnvValue(key string) (string, bool) {
+	sep := string(c.opts.Delimiter)
+	keys := strings.Split(key, sep)
+
+	names := c.layerOrder()
+	for i := len(names) - 1; i >= 0 && names[i] != LayerEnv; i-- {
+		if _, ok := findByKeys(c.layers[names[i]], keys); ok {
+			return "", false
+		}
+	}
+	return c.envValue(key)
+}
+
+// find the ENV value of the key, by the binding names or the automatic ENV name.
+func (c *Config) envValue(key string) (string, bool) {
+	if names, ok := c.envBinds[key]; ok {
+		if len(names) == 0 {
+			names = []string{c.envName(key)}
+		}
+
+		for _, name := range names {
+			if val, ok := os.LookupEnv(name); ok {
+				return val, true
+			}
+		}
+	}
+
+	if c.autoEnv {
+		return os.LookupEnv(c.envName(key))
+	}
+	return "", false
+}
+
+// override the values in the data by the ENV values. key is the key path of the data.
+func (c *Config) overlayEnv(key string, data interface{}) interface{} {
+	sep := string(c.opts.Delimiter)
+	out := c.overlayEnvValue(key, data)
+
+	mp, ok := out.(map[string]interface{})
+	if !ok {
+		return out
+	}
+
+	// the bound keys which not exists in the data
+	for bindKey := range c.envBinds {
+		subKey := bindKey
+		if key != "" {
+			if !strings.HasPrefix(bindKey, key+sep) {
+				continue
+			}
+			subKey = bindKey[len(key)+1:]
+		}
+
+		if _, ok := findByKeys(mp, strings.Split(subKey, sep)); ok {
+			continue
+		}
+
+		if val, ok := c.layerEnvValue(bindKey); ok {
+			_ = setValue(mp, subKey, val, c.opts.Delimiter, true)
+		}
+	}
+	return mp
+}
+
+// build a new value by override the leaf values by ENV.
+func (c *Config) overlayEnvValue(key string, val interface{}) interface{} {
+	mp := toStringMap(val)
+	if mp == nil {
+		if env, ok := c.layerEnvValue(key); ok {
+			return convertLike(env, val)
+		}
+		return val
+	}
+
+	sep := string(c.opts.Delimiter)
+	out := make(map[string]interface{}, len(mp))
+	for k, v := range mp {
+		subKey := k
+		if key != "" {
+			subKey = key + sep + k
+		}
+		out[k] = c.overlayEnvValue(subKey, v)
+	}
+	return out
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_LoadEnvPrefix(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"name": "app", "db": {"host": "localhost", "port": 3306, "ssl": false}, "rate": 0.5}`))
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	testutil.MockEnvValues(map[string]string{
+		"TEST_APP_NAME":         "my-app",
+		"TEST_APP_DB__HOST":     "127.0.0.1",
+		"TEST_APP_DB__PORT":     "3307",
+		"TEST_APP_DB__SSL":      "true",
+		"TEST_APP_RATE":         "0.8",
+		"TEST_APP_LOG_LEVEL":    "debug",
+		"TEST_APP_CACHE__MAX__": "100",
+		"TEST_APP_TAGS":         "a, b",
+		"TEST_APPX_NAME":        "other",
+	}, func() {
+		is.NoError(c.LoadEnvPrefix("TEST_APP_"))
+	})
+
+	is.Equal("my-app", c.String("name"))
+	is.Equal("127.0.0.1", c.String("db.host"))
+	is.Equal(3307, c.Int("db.port"))
+	is.Equal(float64(3307), c.Get("db.port"))
+	is.Equal(true, c.Get("db.ssl"))
+	is.Equal(0.8, c.Get("rate"))
+	is.Equal("debug", c.String("log_level"))
+	is.Equal("100", c.Get("cache.max"))
+	// not parse list by default
+	is.Equal("a, b", c.Get("tags"))
+	is.False(c.Exists("x_name"))
+
+	is.Equal(LayerEnv, c.Origin("db.host"))
+	is.Equal(LayerEnv, c.Origin("name"))
+	is.Len(events, 1)
+	is.Equal(&Event{Name: OnLoadData, Keys: []string{"cache", "db", "log_level", "name", "rate", "tags"}, Source: LayerEnv}, events[0])
+
+	// the runtime value has higher priority
+	is.NoError(c.Set("name", "runtime"))
+	testutil.MockEnvValue("TEST_APP_NAME", "my-app2", func(_ string) {
+		is.NoError(c.LoadEnvPrefix("TEST_APP_"))
+	})
+	is.Equal("runtime", c.String("name"))
+
+	// convert like the value below the ENV layer, not the previous ENV value
+	testutil.MockEnvValue("TEST_APP_DB__PORT", "invalid", func(_ string) {
+		is.NoError(c.LoadEnvPrefix("TEST_APP_"))
+	})
+	is.Equal("invalid", c.Get("db.port"))
+	testutil.MockEnvValue("TEST_APP_DB__PORT", "3308", func(_ string) {
+		is.NoError(c.LoadEnvPrefix("TEST_APP_"))
+	})
+	is.Equal(float64(3308), c.Get("db.port"))
+
+	// no matched ENV
+	is.NoError(c.LoadEnvPrefix("NOT_EXISTS_PREFIX_"))
+	is.Len(events, 4)
+}
+
+func TestConfig_LoadEnvPrefix_options(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadData(map[string]interface{}{
+		"ports": []int{80},
+		"debug": false,
+		"title": "x",
+	}))
+
+	testutil.MockEnvValues(map[string]string{
+		"TEST_APP_DB_HOST":    "localhost",
+		"TEST_APP_Max_Conn":   "10",
+		"TEST_APP_PORTS":      "8080,8081",
+		"TEST_APP_DEBUG":      "yes",
+		"TEST_APP_NAMES":      `["a", "b", 1]`,
+		"TEST_APP_BAD_JSON":   `[a,b`,
+		"TEST_APP_SINGLE_ONE": "one",
+		"TEST_APP_TITLE":      "a,b",
+	}, func() {
+		is.NoError(c.LoadEnvPrefix("TEST_APP_", func(o *EnvOptions) {
+			o.WordSep = "."
+			o.ParseList = true
+		}))
+	})
+
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal("10", c.String("max.conn"))
+	is.Equal([]interface{}{8080, 8081}, c.Get("ports"))
+	is.Equal([]int{8080, 8081}, c.Ints("ports"))
+	// convert fail, keep string
+	is.Equal("yes", c.Get("debug"))
+	is.Equal([]interface{}{"a", "b", float64(1)}, c.Get("names"))
+	is.Equal([]interface{}{"[a", "b"}, c.Get("bad.json"))
+	is.Equal("one", c.Get("single.one"))
+	// not parse list for the exists string value
+	is.Equal("a,b", c.Get("title"))
+
+	// keep case
+	c = New("test")
+	testutil.MockEnvValues(map[string]string{
+		"TEST_APP_DB_HOST":  "localhost",
+		"TEST_APP_Max_Conn": "10",
+		"TEST_APP_TAGS":     "a|b",
+	}, func() {
+		is.NoError(LoadEnvPrefix("TEST_APP_")) // package level
+		is.NoError(c.LoadEnvPrefix("TEST_APP_", func(o *EnvOptions) {
+			o.NestSep = "_"
+			o.KeepCase = true
+			o.ParseList = true
+			o.ListSep = "|"
+		}))
+	})
+
+	is.Equal("localhost", c.String("DB.HOST"))
+	is.Equal("10", c.String("Max.Conn"))
+	is.Equal([]string{"a", "b"}, c.Strings("TAGS"))
+	is.Equal("localhost", String("db_host"))
+	ClearAll()
+}
+
+func TestConvertLike(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal("abc", convertLike("abc", nil))
+	is.Equal(true, convertLike("true", false))
+	is.Equal(int8(12), convertLike("12", int8(1)))
+	is.Equal("300", convertLike("300", int8(1)))
+	is.Equal(uint(12), convertLike("12", uint(1)))
+	is.Equal("-12", convertLike("-12", uint(1)))
+	is.Equal(float32(1.5), convertLike("1.5", float32(1)))
+	is.Equal("1.5", convertLike("1.5", "str"))
+}
+
+func TestConfig_AutomaticEnv(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"name": "app", "db": {"host": "localhost", "port": 3306}, "log-level": "info"}`))
+
+	testutil.MockEnvValues(map[string]string{
+		"TEST_APP_DB_HOST":   "127.0.0.1",
+		"TEST_APP_DB_PORT":   "3307",
+		"TEST_APP_LOG_LEVEL": "debug",
+		"TEST_APP_NEW_KEY":   "val",
+	}, func() {
+		// not enabled
+		is.Equal("localhost", c.String("db.host"))
+
+		c.AutomaticEnv("TEST_APP_")
+		is.Equal("127.0.0.1", c.String("db.host"))
+		is.Equal(3307, c.Int("db.port"))
+		is.Equal(float64(3307), c.Get("db.port"))
+		is.Equal("debug", c.String("log-level"))
+		is.Equal("app", c.String("name"))
+		is.Equal("val", c.String("new.key"))
+		is.True(c.Exists("new.key"))
+		is.False(c.Exists("not.exists"))
+
+		is.Equal(map[string]interface{}{"host": "127.0.0.1", "port": float64(3307)}, c.Get("db"))
+		is.Equal(LayerEnv, c.Origin("db.host"))
+		is.Equal(LayerFiles, c.Origin("name"))
+
+		db := struct {
+			Host string
+			Port int
+		}{}
+		is.NoError(c.Structure("db", &db))
+		is.Equal("127.0.0.1", db.Host)
+		is.Equal(3307, db.Port)
+
+		all := struct {
+			Name string
+			Db   struct {
+				Host string
+			}
+		}{}
+		is.NoError(c.Structure("", &all))
+		is.Equal("127.0.0.1", all.Db.Host)
+
+		// view
+		sub := c.Sub("db")
+		is.Equal("127.0.0.1", sub.String("host"))
+		is.True(sub.Exists("port"))
+
+		// the flags and runtime values override the ENV
+		is.NoError(c.SetLayer(LayerFlags, map[string]interface{}{"db": map[string]interface{}{"port": 3310}}))
+		is.NoError(c.Set("db.host", "runtime.host"))
+		is.Equal("runtime.host", c.String("db.host"))
+		is.Equal(3310, c.Int("db.port"))
+		is.Equal("debug", c.String("log-level"))
+		is.Equal(LayerRuntime, c.Origin("db.host"))
+		is.Equal(LayerFlags, c.Origin("db.port"))
+		is.Equal(LayerEnv, c.Origin("log-level"))
+		is.NoError(c.Structure("db", &db))
+		is.Equal("runtime.host", db.Host)
+		is.Equal(3310, db.Port)
+	})
+
+	// ENV is removed
+	is.Equal("runtime.host", c.String("db.host"))
+	is.Equal("info", c.String("log-level"))
+
+	AutomaticEnv("TEST_APP")
+	is.True(Default().autoEnv)
+	is.Equal("TEST_APP", Default().envPrefix)
+	Default().autoEnv, Default().envPrefix = false, ""
+}
+
+func TestConfig_BindEnv(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost", "port": 3306}}`))
+	is.Error(c.BindEnv(" "))
+	is.NoError(c.BindEnv("db.host", "TEST_DATABASE_HOST", "TEST_PGHOST"))
+	is.NoError(c.BindEnv("db.user", "TEST_PGUSER"))
+
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(LayerFiles, c.Origin("db.host"))
+	is.False(c.Exists("db.user"))
+
+	testutil.MockEnvValues(map[string]string{
+		"TEST_PGHOST":  "pg.local",
+		"TEST_PGUSER":  "admin",
+		"TEST_DB_PORT": "3307",
+	}, func() {
+		is.Equal("pg.local", c.String("db.host"))
+		is.Equal(LayerEnv, c.Origin("db.host"))
+		is.Equal("admin", c.String("db.user"))
+		is.True(c.Exists("db.user"))
+		// not bound, not enabled automatic env
+		is.Equal(3306, c.Int("db.port"))
+
+		testutil.MockEnvValue("TEST_DATABASE_HOST", "db.local", func(_ string) {
+			is.Equal("db.local", c.String("db.host"))
+		})
+
+		info := struct {
+			Host string
+			User string
+		}{}
+		is.NoError(c.Structure("db", &info))
+		is.Equal("pg.local", info.Host)
+		is.Equal("admin", info.User)
+
+		// bind by the automatic name
+		sub := c.Sub("db")
+		is.NoError(sub.BindEnv("port"))
+		c.AutomaticEnv("TEST")
+		is.Equal(3307, c.Int("db.port"))
+		is.Equal(3307, sub.Int("port"))
+
+		// key not exists in data, but has bound children
+		c2 := New("test")
+		is.NoError(c2.BindEnv("db.user", "TEST_PGUSER"))
+		is.Equal(map[string]interface{}{"user": "admin"}, c2.Get("db"))
+	})
+
+	is.NoError(BindEnv("db.host"))
+	is.Contains(Default().envBinds, "db.host")
+	Default().envBinds = nil
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Event the config data changed event
+type Event struct {
+	// Name of the event. eg: OnSetValue, OnLoadData
+	Name string
+	// Keys the changed keys. for load event, they are the top keys of the loaded data.
+	// empty on the whole data changed. eg: SetData(), ClearData()
+	Keys []string
+	// Source the layer name which data is changed. eg: LayerRuntime, LayerFiles
+	Source string
+}
+
+// EventHandler func for handle the config event
+type EventHandler func(e *Event)
+
+// Subscribe add an event handler, returns func for unsubscribe.
+func Subscribe(mask string, fn EventHandler) func() { return dc.Subscribe(mask, fn) }
+
+// Subscribe add an event handler, the mask is the event name, allow use "*" as wildcard.
+// eg: "set.*", "*". returns func for unsubscribe.
+//
+// The handlers are called in registration order, and the panic in a handler will be
+// recovered and recorded as error, see Config.Error(). set the option AsyncEvents
+// for call the handlers in a background goroutine.
+//
+// If the config is a view, only the events related to the view keys will be received.
+//
+// Usage:
+//
+//	unsub := c.Subscribe("set.*", func(e *config.Event) {
+//		fmt.Println(e.Name, e.Keys, e.Source)
+//	})
+//	defer unsub()
+func (c *Config) Subscribe(mask string, fn EventHandler) func() {
+	if c.root != nil {
+		return c.root.events.subscribe(mask, c.prefix, string(c.opts.Delimiter), fn)
+	}
+	return c.events.subscribe(mask, "", "", fn)
+}
+
+// SubscribeChan subscribe events by channel, returns func for unsubscribe.
+func SubscribeChan(mask string, size int) (<-chan *Event, func()) {
+	return dc.SubscribeChan(mask, size)
+}
+
+// SubscribeChan subscribe events by a channel with the buffer size. the event will
+// be dropped on the channel is full. the channel will be closed on unsubscribe.
+//
+// Usage:
+//
+//	ch, unsub := c.SubscribeChan("load.*", 10)
+//	defer unsub()
+//
+//	for e := range ch {
+//		fmt.Println(e.Name, e.Source)
+//	}
+func (c *Config) SubscribeChan(mask string, size int) (<-chan *Event, func()) {
+	ch := make(chan *Event, size)
+
+	var lock sync.Mutex
+	var closed bool
+	unsub := c.Subscribe(mask, func(e *Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if !closed {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	})
+
+	return ch, func() {
+		unsub()
+
+		lock.Lock()
+		defer lock.Unlock()
+		if !closed {
+			closed = true
+			close(ch)
+		}
+	}
+}
+
+// fire the event with the layer name and changed keys.
+func (c *Config) fireEvent(name, source string, keys ...string) {
+	// the view only calls own hook func, the subscribers are in the root.
+	if c.root != nil {
+		if c.opts.HookFunc != nil {
+			c.opts.HookFunc(name, c)
+		}
+		return
+	}
+
+	// the data is changed, drop the cached values.
+	c.ClearCaches()
+
+	e := &Event{Name: name, Keys: keys, Source: source}
+	if c.opts.AsyncEvents {
+		c.events.publish(func() { c.dispatch(e) })
+		return
+	}
+	c.dispatch(e)
+}
+
+// call the hook func, subscribers and auto saver in order.
+func (c *Config) dispatch(e *Event) {
+	if c.opts.HookFunc != nil {
+		c.callHandler(func() { c.opts.HookFunc(e.Name, c) })
+	}
+
+	for _, sub := range c.events.handlers() {
+		if sub.match(e) {
+			c.callHandler(func() { sub.fn(e) })
+		}
+	}
+	c.autoSave(e.Name)
+}
+
+// call the handler, recover the panic and record it as error.
+func (c *Config) callHandler(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.addError(fmt.Errorf("config: panic in event handler: %v", r))
+		}
+	}()
+	fn()
+}
+
+// get the top keys of the data list, for the event keys.
+func topKeys(list ...map[string]interface{}) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, data := range list {
+		for key := range data {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+type subscriber struct {
+	id   int
+	mask string
+	fn   EventHandler
+	// key prefix of the view
+	prefix string
+	sep    string
+}
+
+// match the event name and keys
+func (s *subscriber) match(e *Event) bool {
+	if s.mask != "" && !wildcardMatch(s.mask, e.Name) {
+		return false
+	}
+
+	if s.prefix == "" || len(e.Keys) == 0 {
+		return true
+	}
+
+	// the key is in the view, or is a parent key of the view
+	for _, key := range e.Keys {
+		if key == s.prefix || strings.HasPrefix(key, s.prefix+s.sep) || strings.HasPrefix(s.prefix, key+s.sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventBus manage the event subscribers, and dispatch events in background on async mode.
+type eventBus struct {
+	lock   sync.Mutex
+	subs   []*subscriber
+	nextID int
+
+	// queue of the async events, dispatch by a goroutine in order.
+	queue   []func()
+	running bool
+	wg      sync.WaitGroup
+}
+
+func (b *eventBus) subscribe(mask, prefix, sep string, fn EventHandler) func() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, &subscriber{id: id, mask: mask, fn: fn, prefix: prefix, sep: sep})
+
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		for i, sub := range b.subs {
+			if sub.id == id {
+				// copy on write, the dispatching handlers are not affected.
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (b *eventBus) handlers() []*subscriber {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.subs
+}
+
+// add the dispatch func to queue, and start the dispatch goroutine if not running.
+func (b *eventBus) publish(fn func()) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.queue = append(b.queue, fn)
+	if b.running {
+		return
+	}
+
+	b.running = true
+	b.wg.Add(1)
+	go b.run()
+}
+
+func (b *eventBus) run() {
+	defer b.wg.Done()
+
+	for {
+		b.lock.Lock()
+		if len(b.queue) == 0 {
+			b.running = false
+			b.lock.Unlock()
+			return
+		}
+
+		fn := b.queue[0]
+		b.queue = b.queue[1:]
+		b.lock.Unlock()
+
+		fn()
+	}
+}
+
+// wait for the async events are dispatched
+func (b *eventBus) wait() {
+	b.wg.Wait()
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Subscribe(t *testing.T) {
+	is := assert.New(t)
+
+	var calls []string
+	c := NewWithOptions("test", WithHookFunc(func(event string, c *Config) {
+		calls = append(calls, "hook:"+event)
+	}))
+
+	unsub1 := c.Subscribe("set.*", func(e *Event) {
+		calls = append(calls, "sub1:"+e.Name)
+	})
+	c.Subscribe("*", func(e *Event) {
+		calls = append(calls, "sub2:"+e.Name)
+	})
+
+	var events []*Event
+	c.Subscribe("", func(e *Event) {
+		events = append(events, e)
+	})
+
+	is.NoError(c.LoadData(map[string]interface{}{"name": "app", "db": map[string]interface{}{"host": "localhost"}}))
+	is.NoError(c.Set("db.port", 3306))
+	is.Equal([]string{
+		"hook:load.data", "sub2:load.data",
+		"hook:set.value", "sub1:set.value", "sub2:set.value",
+	}, calls)
+
+	is.Len(events, 2)
+	is.Equal(&Event{Name: OnLoadData, Keys: []string{"db", "name"}, Source: LayerFiles}, events[0])
+	is.Equal(&Event{Name: OnSetValue, Keys: []string{"db.port"}, Source: LayerRuntime}, events[1])
+
+	// unsubscribe
+	calls = calls[:0]
+	unsub1()
+	unsub1()
+	is.NoError(c.Set("name", "new-app"))
+	is.Equal([]string{"hook:set.value", "sub2:set.value"}, calls)
+
+	// other events
+	events = events[:0]
+	is.NoError(c.SetLayer(LayerEnv, map[string]interface{}{"debug": true}))
+	c.ClearData()
+	is.Len(events, 2)
+	is.Equal(&Event{Name: OnSetData, Source: LayerEnv}, events[0])
+	is.Equal(&Event{Name: OnCleanData}, events[1])
+
+	// package level
+	unsub := Subscribe("set.*", func(e *Event) {})
+	unsub()
+}
+
+func TestConfig_Subscribe_loadSource(t *testing.T) {
+	is := assert.New(t)
+
+	var calls []string
+	c := NewWithOptions("test", WithHookFunc(func(event string, c *Config) {
+		calls = append(calls, "hook:"+event)
+	}))
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	// fire the load event after the source is parsed
+	is.NoError(c.LoadStrings(JSON, `{"name": "app", "db": {"host": "localhost"}}`))
+	is.Equal([]string{"hook:load.data"}, calls)
+	is.Len(events, 1)
+	is.Equal(&Event{Name: OnLoadData, Keys: []string{"db", "name"}, Source: LayerFiles}, events[0])
+
+	// not fire on decode error
+	is.Error(c.LoadStrings(JSON, `{invalid`))
+	is.Error(c.LoadSources(JSON, []byte(`{"debug": true}`), []byte(`invalid`)))
+	is.Len(calls, 2)
+	is.Len(events, 2)
+	is.Equal([]string{"debug"}, events[1].Keys)
+}
+
+func TestConfig_Subscribe_handler(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	var calls []string
+	c.Subscribe("set.*", func(e *Event) {
+		calls = append(calls, "panic")
+		panic("handler error")
+	})
+
+	// can read and write the config in the handler
+	c.Subscribe("set.*", func(e *Event) {
+		calls = append(calls, c.String("name"))
+		if !c.Exists("copy") {
+			is.NoError(c.Set("copy", c.String("name")))
+		}
+	})
+
+	is.NotPanics(func() {
+		is.NoError(c.Set("name", "app"))
+	})
+	is.Equal([]string{"panic", "app", "panic", "app"}, calls)
+	is.Equal("app", c.String("copy"))
+
+	err := c.Error()
+	is.Error(err)
+	is.Contains(err.Error(), "handler error")
+}
+
+func TestConfig_SubscribeChan(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	ch, unsub := c.SubscribeChan("set.*", 2)
+
+	is.NoError(c.LoadData(map[string]interface{}{"name": "app"}))
+	is.NoError(c.Set("name", "app1"))
+	is.NoError(c.Set("age", 23))
+	// the channel is full, will drop the event
+	is.NoError(c.Set("debug", true))
+
+	e := <-ch
+	is.Equal(OnSetValue, e.Name)
+	is.Equal([]string{"name"}, e.Keys)
+	e = <-ch
+	is.Equal([]string{"age"}, e.Keys)
+
+	unsub()
+	unsub()
+	_, ok := <-ch
+	is.False(ok)
+
+	// not send after unsubscribe
+	is.NoError(c.Set("debug", false))
+
+	ch, unsub = SubscribeChan("*", 1)
+	unsub()
+	_, ok = <-ch
+	is.False(ok)
+}
+
+func TestConfig_Subscribe_view(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost"}, "name": "app"}`))
+
+	var keys []string
+	db := c.Sub("db")
+	db.Subscribe("*", func(e *Event) {
+		keys = append(keys, e.Name+":"+strings.Join(e.Keys, ","))
+	})
+
+	is.NoError(c.Set("name", "new-app"))
+	is.NoError(c.Set("db.port", 3306))
+	is.NoError(db.Set("user", "root"))
+	is.NoError(c.Set("db", map[string]interface{}{"host": "127.0.0.1"}))
+	is.NoError(c.LoadData(map[string]interface{}{"cache": map[string]interface{}{"host": "localhost"}}))
+	is.NoError(c.LoadData(map[string]interface{}{"db": map[string]interface{}{"port": 3307}}))
+	c.ClearData()
+
+	is.Equal([]string{
+		"set.value:db.port",
+		"set.value:db.user",
+		"set.value:db",
+		"load.data:db",
+		"clean.data:",
+	}, keys)
+}
+
+func TestConfig_Subscribe_async(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", AsyncEvents)
+	is.True(c.Options().AsyncEvents)
+
+	var lock sync.Mutex
+	var names []string
+	block := make(chan struct{})
+	c.Subscribe("set.*", func(e *Event) {
+		<-block
+
+		lock.Lock()
+		names = append(names, e.Keys[0])
+		lock.Unlock()
+	})
+
+	// not blocked by the handler
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			is.NoError(c.Set(string(rune('a'+i)), i))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the Set is blocked by the event handler")
+	}
+
+	close(block)
+	// wait for the events are dispatched
+	is.NoError(c.Close())
+
+	lock.Lock()
+	defer lock.Unlock()
+	is.Equal([]string{"a", "b", "c", "d", "e"}, names)
+}
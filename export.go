@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -53,15 +55,16 @@ func (c *Config) MapOnExists(key string, dst interface{}) error {
 //	dbInfo := Db{}
 //	config.Structure("db", &dbInfo)
 func (c *Config) Structure(key string, dst interface{}) error {
-	var data interface{}
-	if key == "" { // binding all data
-		data = c.data
-	} else { // some data of the config
-		var ok bool
-		data, ok = c.GetValue(key)
-		if !ok {
-			return errNotFound
+	if c.root != nil {
+		if key = c.viewKey(key); key == "" {
+			key = c.prefix
 		}
+		return c.root.Structure(key, dst)
+	}
+
+	data, err := c.structureData(key)
+	if err != nil {
+		return err
 	}
 
 	var bindConf *mapstructure.DecoderConfig
@@ -77,16 +80,57 @@ func (c *Config) Structure(key string, dst interface{}) error {
 
 	// add hook on decode value to struct
 	if bindConf.DecodeHook == nil && c.opts.shouldAddHookFunc() {
-		bindConf.DecodeHook = ValDecodeHookFunc(c.opts.ParseEnv, c.opts.ParseTime)
+		var envParser func(string) string
+		if c.opts.shouldParseEnv() {
+			envParser = c.parseEnvValue
+		}
+		bindConf.DecodeHook = newValDecodeHook(envParser, c.opts.ParseTime)
 	}
 
+	// mark the fields with tag `secret:"true"` as sensitive
+	c.markSecretFields(formatKey(key, string(c.opts.Delimiter)), dst, bindConf.TagName)
+
 	bindConf.Result = dst // set result struct ptr
 	decoder, err := mapstructure.NewDecoder(bindConf)
 	if err != nil {
 		return err
 	}
 
-	return decoder.Decode(data)
+	if err = decoder.Decode(data); err != nil {
+		return c.redactDataError(formatKey(key, string(c.opts.Delimiter)), data, err)
+	}
+	return nil
+}
+
+// get the data for binding to struct
+func (c *Config) structureData(key string) (data interface{}, err error) {
+	if !c.opts.Readonly {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+	}
+
+	if key == "" { // binding all data
+		data = c.data
+		if c.hasEnvBinding() {
+			data = c.overlayEnv("", data)
+		}
+
+		if c.opts.TrackAccess {
+			for topK := range c.data {
+				c.recordAccess(topK)
+			}
+		}
+	} else { // some data of the config
+		var ok bool
+		if data, ok = c.getValue(key); !ok {
+			return nil, errNotFound
+		}
+	}
+
+	if c.opts.ParseRefs || c.opts.KeyProvider != nil {
+		data, err = c.resolveValue(formatKey(key, string(c.opts.Delimiter)), data, true)
+	}
+	return
 }
 
 // ToJSON string
@@ -109,60 +153,212 @@ func (c *Config) WriteTo(out io.Writer) (n int64, err error) {
 	return c.DumpTo(out, c.opts.DumpFormat)
 }
 
+// DumpOptions for dump config data
+type DumpOptions struct {
+	// ExcludeDefaults dump only the non-default values. see SetDefault()
+	ExcludeDefaults bool
+	// Decrypt dump the decrypted plaintext of the encrypted values. see SetKeyProvider()
+	Decrypt bool
+	// Reveal dump the real values of the sensitive keys. see AddSensitive()
+	Reveal bool
+	// FileMode the file mode on dump to file. if not set, will use 0600 if there are
+	// sensitive keys, otherwise 0644. the mode of the existing file is kept if it's
+	// not wider than the default. eg: 0640 is kept, 0777 is changed to 0644.
+	FileMode os.FileMode
+	// Backups the number of backups of the previous file, on dump to file.
+	// the backup files are named like "app.json.bak.1", the 1 is the newest.
+	Backups int
+}
+
+// ExcludeDefaults dump option, will dump only the non-default values.
+func ExcludeDefaults(opts *DumpOptions) { opts.ExcludeDefaults = true }
+
+// DecryptValues dump option, will dump the decrypted plaintext of the encrypted values.
+func DecryptValues(opts *DumpOptions) { opts.Decrypt = true }
+
+// RevealSecrets dump option, will dump the real values of the sensitive keys.
+func RevealSecrets(opts *DumpOptions) { opts.Reveal = true }
+
+// WithFileMode dump option, set the file mode on dump to file.
+func WithFileMode(mode os.FileMode) func(*DumpOptions) {
+	return func(opts *DumpOptions) {
+		opts.FileMode = mode
+	}
+}
+
+// WithBackups dump option, keep the number of backups of the previous file on dump to file.
+func WithBackups(num int) func(*DumpOptions) {
+	return func(opts *DumpOptions) {
+		opts.Backups = num
+	}
+}
+
 // DumpTo a writer and use format
 func DumpTo(out io.Writer, format string) (int64, error) { return dc.DumpTo(out, format) }
 
-// DumpTo use the format(json,yaml,toml) dump config data to a writer
+// DumpTo use the format(json,yaml,toml) dump config data to a writer.
+// the values of sensitive keys will be masked. see AddSensitive()
 func (c *Config) DumpTo(out io.Writer, format string) (n int64, err error) {
-	var ok bool
-	var encoder Encoder
+	return c.DumpWith(out, format)
+}
 
-	format = fixFormat(format)
-	if encoder, ok = c.encoders[format]; !ok {
-		err = errors.New("not exists/register encoder for the format: " + format)
+// DumpWith use the format(json,yaml,toml) and dump options, dump config data to a writer
+//
+// Usage:
+//
+//	c.DumpWith(os.Stdout, config.JSON, config.ExcludeDefaults)
+func (c *Config) DumpWith(out io.Writer, format string, opts ...func(*DumpOptions)) (n int64, err error) {
+	encoded, err := c.encodeData(format, newDumpOptions(opts))
+	if err != nil || encoded == nil {
 		return
 	}
 
-	// is empty
-	if len(c.data) == 0 {
-		return
+	// write content to out
+	num, _ := fmt.Fprintln(out, string(encoded))
+
+	return int64(num), nil
+}
+
+// DumpToFile use the format(json,yaml,toml) dump config data to a file.
+//
+// The raw data is written for persist: the references are not resolved, the encrypted
+// values are not decrypted and the values of sensitive keys are not masked. the values
+// of EncryptKeys are encrypted. DumpOptions.Decrypt and Reveal are not used.
+//
+// If the format is empty, will use the file ext as format. The file is written
+// atomically(write to a temp file, fsync, rename and fsync the dir), and an advisory
+// lock on the dir is held on write, for stop concurrent writers.
+//
+// Usage:
+//
+//	err := c.DumpToFile("app.json", "", config.WithBackups(3))
+func (c *Config) DumpToFile(fileName string, format string, opts ...func(*DumpOptions)) (err error) {
+	if format == "" {
+		format = strings.Trim(filepath.Ext(fileName), ".")
 	}
 
-	// encode data to string
-	encoded, err := encoder(c.data)
-	if err != nil {
+	dumpOpts := newDumpOptions(opts)
+	encoded, err := c.encodeFile(fileName, format, dumpOpts)
+	if err != nil || encoded == nil {
 		return
 	}
 
-	// write content to out
-	num, _ := fmt.Fprintln(out, string(encoded))
+	wo := &writeFileOptions{perm: dumpOpts.FileMode, backups: dumpOpts.Backups}
+	if wo.perm == 0 {
+		wo.perm = c.fileModeFor(nil)
+		wo.keepPerm = true
+	}
+	return writeFileSafe(fileName, encoded, wo)
+}
 
-	return int64(num), nil
+func newDumpOptions(optFns []func(*DumpOptions)) *DumpOptions {
+	opts := &DumpOptions{}
+	for _, fn := range optFns {
+		fn(opts)
+	}
+	return opts
 }
 
-// DumpToFile use the format(json,yaml,toml) dump config data to a writer
-func (c *Config) DumpToFile(fileName string, format string) (err error) {
-	var ok bool
-	var encoder Encoder
+// encode config data by the format. will return nil on data is empty.
+func (c *Config) encodeData(format string, opts *DumpOptions) (encoded []byte, err error) {
+	return c.encodeFile("", format, opts)
+}
 
+// encode config data for the file, the file path is given to the FileDriver.
+// if the file is not empty, will encode the raw data for persist.
+func (c *Config) encodeFile(file, format string, opts *DumpOptions) (encoded []byte, err error) {
 	format = fixFormat(format)
-	if encoder, ok = c.encoders[format]; !ok {
+	encoder := c.fileEncoder(file, format)
+	if encoder == nil {
 		err = errors.New("not exists/register encoder for the format: " + format)
 		return
 	}
 
 	// is empty
-	if len(c.data) == 0 {
+	data, err := c.dumpData(opts, file != "")
+	if err != nil || len(data) == 0 {
 		return
 	}
 
 	// encode data to string
-	encoded, err := encoder(c.data)
-	if err != nil {
-		return
+	return encoder(data)
+}
+
+// get the encoder for the file, will use the FileDriver on it exists.
+func (c *Config) fileEncoder(file, format string) Encoder {
+	if c.root != nil {
+		return c.root.fileEncoder(file, format)
 	}
 
-	// write content to out
-	//num, _ := fmt.Fprintln(out, string(encoded))
-	return os.WriteFile(fileName, encoded, os.ModePerm)
+	if fd := c.fileDrivers[format]; fd != nil && file != "" {
+		return func(v interface{}) ([]byte, error) {
+			return fd.EncodeFile(file, v)
+		}
+	}
+	return c.encoders[format]
+}
+
+// get the default file mode for write the data. 0600 if has sensitive values, otherwise 0644.
+// if data is nil, will check the config data.
+func (c *Config) fileModeFor(data map[string]interface{}) os.FileMode {
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+
+	if data == nil {
+		data = root.data
+	}
+
+	if len(root.encryptKeys) > 0 {
+		return 0600
+	}
+
+	sensitive := false
+	root.walkSensitive("", data, data, func(val interface{}) interface{} {
+		sensitive = true
+		return val
+	})
+
+	if sensitive {
+		return 0600
+	}
+	return 0644
+}
+
+// get config data for dump. if persist is true, will get the raw data for write to file.
+func (c *Config) dumpData(opts *DumpOptions, persist bool) (data map[string]interface{}, err error) {
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+
+	if opts.ExcludeDefaults {
+		data = root.nonDefaultData()
+	} else {
+		data = root.data
+	}
+	raw := data
+
+	// resolve references, decrypt values only on explicitly requested.
+	if !persist && (root.opts.ParseRefs || opts.Decrypt) {
+		data = root.resolveData(data, opts.Decrypt)
+	}
+
+	if (persist || !opts.Decrypt) && len(root.encryptKeys) > 0 {
+		if data, err = root.encryptData(data); err != nil {
+			return
+		}
+	}
+
+	// mask the values of sensitive keys
+	if !persist && !opts.Reveal && len(data) > 0 {
+		data = root.redactData(raw, data)
+	}
+
+	if c.IsView() {
+		val, _ := findByKeys(data, strings.Split(c.prefix, string(c.opts.Delimiter)))
+		data = toStringMap(val)
+	}
+	return
 }
@@ -2,6 +2,10 @@ package config
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/gookit/goutil/dump"
@@ -280,3 +284,154 @@ func TestConfig_BindStruct_set_DecoderConfig(t *testing.T) {
 func TestConfig_BindStruct_error(t *testing.T) {
 	// cfg := NewEmpty()
 }
+
+func TestConfig_DumpToFile(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.json")
+
+	c := New("test")
+	// empty data, not write file
+	is.NoError(c.DumpToFile(file, ""))
+	is.NoFileExists(file)
+
+	is.NoError(c.LoadData(map[string]interface{}{"name": "app"}))
+	// infer format from the file ext
+	is.NoError(c.DumpToFile(file, ""))
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"name":"app"}`, string(bts))
+
+	is.Error(c.DumpToFile(filepath.Join(dir, "app.toml"), ""))
+	is.Error(c.DumpToFile(filepath.Join(dir, "not-exist", "app.json"), ""))
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(file)
+		is.NoError(err)
+		is.Equal(os.FileMode(0644), fi.Mode().Perm())
+
+		// keep the mode of existing file
+		is.NoError(os.Chmod(file, 0640))
+		is.NoError(c.DumpToFile(file, JSON))
+		fi, err = os.Stat(file)
+		is.NoError(err)
+		is.Equal(os.FileMode(0640), fi.Mode().Perm())
+
+		// set mode
+		is.NoError(c.DumpToFile(file, JSON, WithFileMode(0600)))
+		fi, err = os.Stat(file)
+		is.NoError(err)
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+		// has sensitive values
+		secFile := filepath.Join(dir, "secret.json")
+		is.NoError(c.Set("db.password", "s3cret"))
+		c.AddSensitive("*.password")
+		is.NoError(c.DumpToFile(secFile, JSON))
+		fi, err = os.Stat(secFile)
+		is.NoError(err)
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+		// the wide mode of the existing file is not kept
+		oldFile := filepath.Join(dir, "old.json")
+		is.NoError(ioutil.WriteFile(oldFile, []byte(`{}`), 0777))
+		is.NoError(os.Chmod(oldFile, 0777))
+		is.NoError(c.DumpToFile(oldFile, "", WithBackups(1)))
+		fi, err = os.Stat(oldFile)
+		is.NoError(err)
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+		fi, err = os.Stat(oldFile + ".bak.1")
+		is.NoError(err)
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+	}
+}
+
+func TestConfig_DumpToFile_refs(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	secFile := filepath.Join(dir, "secret")
+	is.NoError(ioutil.WriteFile(secFile, []byte("s3cr3t"), 0600))
+
+	tpl := `{"db":{"host":"h","password":"${file:` + filepath.ToSlash(secFile) + `}"},"url":"http://${db.host}/x"}`
+	file := filepath.Join(dir, "app.json")
+	is.NoError(ioutil.WriteFile(file, []byte(tpl), 0600))
+
+	c := NewWithOptions("test", ParseRefs, EnableResolvers("file"), WithAutoSave(file))
+	is.NoError(c.LoadFiles(file))
+	is.Equal("s3cr3t", c.String("db.password"))
+	is.Equal("http://h/x", c.String("url"))
+	// resolved for display
+	is.Contains(c.ToJSON(), `"url":"http://h/x"`)
+
+	// persist the templates, not the resolved values
+	is.NoError(c.DumpToFile(file, ""))
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.JSONEq(tpl, string(bts))
+
+	// auto save
+	is.NoError(c.Set("db.host", "h2"))
+	bts, err = ioutil.ReadFile(file)
+	is.NoError(err)
+	is.NotContains(string(bts), "s3cr3t")
+	is.Contains(string(bts), `"url":"http://${db.host}/x"`)
+
+	c2 := NewWithOptions("test", ParseRefs, EnableResolvers("file"))
+	is.NoError(c2.LoadFiles(file))
+	is.Equal("s3cr3t", c2.String("db.password"))
+	is.Equal("http://h2/x", c2.String("url"))
+}
+
+func TestConfig_DumpToFile_backups(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.json")
+	c := New("test")
+
+	for _, name := range []string{"v1", "v2", "v3", "v4"} {
+		is.NoError(c.Set("name", name))
+		is.NoError(c.DumpToFile(file, JSON, WithBackups(2)))
+	}
+
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{"name":"v4"}`, string(bts))
+
+	bts, err = ioutil.ReadFile(file + ".bak.1")
+	is.NoError(err)
+	is.Equal(`{"name":"v3"}`, string(bts))
+
+	bts, err = ioutil.ReadFile(file + ".bak.2")
+	is.NoError(err)
+	is.Equal(`{"name":"v2"}`, string(bts))
+	is.NoFileExists(file + ".bak.3")
+
+	// no temp files left
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(file), ".app.json.tmp-*"))
+	is.NoError(err)
+	is.Empty(matches)
+}
+
+func TestConfig_DumpToFile_concurrent(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.json")
+	done := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			c := New("test")
+			_ = c.Set("id", i)
+			done <- c.DumpToFile(file, JSON, WithBackups(1))
+		}(i)
+	}
+
+	for i := 0; i < 8; i++ {
+		is.NoError(<-done)
+	}
+
+	c := New("test")
+	is.NoError(c.LoadFilesByFormat(JSON, file, file+".bak.1"))
+	is.True(c.Exists("id"))
+}
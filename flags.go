@@ -0,0 +1,351 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/strutil"
+)
+
+// FlagSpec the spec for bind a cli flag to the config key. see LoadFlagSet()
+type FlagSpec struct {
+	// Key the config key path. eg: "db.host"
+	Key string
+	// Name the flag name. default is the Key with the delimiter replaced by "-". eg: "db-host"
+	Name string
+	// Type the value type. allow: int, uint, bool, float, duration, strings, map, string(default)
+	//
+	//  - strings: the flag is repeatable, and the value will be split by ",". eg: --tag a --tag b,c
+	//  - map: the flag is repeatable, the value format is "key=value". eg: --label env=prod
+	//  - duration: the value is stored as the duration string. eg: "1m30s"
+	Type string
+	// Usage the description of the flag
+	Usage string
+}
+
+// LoadFlagSet load data from the cli flags
+func LoadFlagSet(fs *flag.FlagSet, args []string, specs ...FlagSpec) error {
+	return dc.LoadFlagSet(fs, args, specs...)
+}
+
+// LoadFlagSet bind the flags to the flag set by specs, parse the args and load
+// the values of the given flags. the current config values are used as the default
+// values of the flags.
+//
+// If the flag has been defined in the flag set, will use it and not redefine.
+//
+// Usage:
+//
+//	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+//	err := c.LoadFlagSet(fs, os.Args[1:],
+//		config.FlagSpec{Key: "db.host", Usage: "the database host"}, // flag: --db-host
+//		config.FlagSpec{Key: "db.timeout", Type: "duration"},
+//		config.FlagSpec{Key: "tags", Type: "strings"},
+//	)
+func (c *Config) LoadFlagSet(fs *flag.FlagSet, args []string, specs ...FlagSpec) (err error) {
+	c = c.loadIn(LayerFlags)
+
+	sep := string(c.opts.Delimiter)
+	bound := make(map[string]*FlagSpec, len(specs))
+	for i := range specs {
+		spec := &specs[i]
+		if spec.Key = formatKey(spec.Key, sep); spec.Key == "" {
+			return errKeyIsEmpty
+		}
+
+		if spec.Name == "" {
+			spec.Name = strings.Replace(spec.Key, sep, "-", -1)
+		}
+
+		if fs.Lookup(spec.Name) == nil {
+			c.defineFlag(fs, spec)
+		}
+		bound[spec.Name] = spec
+	}
+
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	// collect the given flags
+	var loaded []string
+	fs.Visit(func(f *flag.Flag) {
+		spec, ok := bound[f.Name]
+		if !ok || err != nil {
+			return
+		}
+
+		var val interface{} = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			val = getter.Get()
+		}
+
+		// store the duration as string, same as the value in the config files. eg: "1m30s"
+		if d, ok := val.(time.Duration); ok {
+			val = d.String()
+		}
+
+		// set the map entries, keep other entries in the config
+		if mv, ok := val.(map[string]string); ok {
+			keys := make([]string, 0, len(mv))
+			for k := range mv {
+				keys = append(keys, k)
+			}
+
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err = c.Set(spec.Key+sep+k, mv[k]); err != nil {
+					return
+				}
+			}
+		} else if err = c.Set(spec.Key, val); err != nil {
+			return
+		}
+		loaded = append(loaded, spec.Key)
+	})
+
+	if err == nil {
+		c.notify(OnLoadData, c.layerFor(LayerFlags), loaded...)
+	}
+	return
+}
+
+// define the flag by spec type, the default value is the config value.
+func (c *Config) defineFlag(fs *flag.FlagSet, spec *FlagSpec) {
+	val, _ := c.flagDefault(spec.Key)
+	str, _ := strutil.AnyToString(val, false)
+
+	switch spec.Type {
+	case "int":
+		def, _ := strconv.Atoi(str)
+		fs.Int(spec.Name, def, spec.Usage)
+	case "uint":
+		def, _ := strconv.ParseUint(str, 10, 0)
+		fs.Uint(spec.Name, uint(def), spec.Usage)
+	case "bool":
+		def, _ := strconv.ParseBool(str)
+		fs.Bool(spec.Name, def, spec.Usage)
+	case "float":
+		def, _ := strconv.ParseFloat(str, 64)
+		fs.Float64(spec.Name, def, spec.Usage)
+	case "duration":
+		def, _ := time.ParseDuration(str)
+		if d, ok := val.(time.Duration); ok {
+			def = d
+		}
+		fs.Duration(spec.Name, def, spec.Usage)
+	case "strings":
+		var def []string
+		switch typVal := val.(type) {
+		case []string:
+			def = typVal
+		case []interface{}:
+			def = arrutil.SliceToStrings(typVal)
+		}
+		fs.Var(&stringsValue{def: def}, spec.Name, spec.Usage)
+	case "map":
+		var def map[string]string
+		if mp := toStringMap(val); mp != nil {
+			def = make(map[string]string, len(mp))
+			for k, v := range mp {
+				def[k], _ = strutil.AnyToString(v, false)
+			}
+		}
+		fs.Var(&mapValue{def: def}, spec.Name, spec.Usage)
+	default: // as string
+		fs.String(spec.Name, str, spec.Usage)
+	}
+}
+
+// get the current value of the key for the flag default, not record the access. see Options.TrackAccess
+func (c *Config) flagDefault(key string) (interface{}, bool) {
+	root := c.rootConfig()
+	root.lock.RLock()
+	defer root.lock.RUnlock()
+	return findByKeys(root.data, strings.Split(c.viewKey(key), string(c.opts.Delimiter)))
+}
+
+// stringsValue the repeatable flag value, the value will be split by ","
+type stringsValue struct {
+	def  []string
+	vals []string
+}
+
+// String get the flag value string
+func (v *stringsValue) String() string {
+	if v.vals == nil {
+		return strings.Join(v.def, ",")
+	}
+	return strings.Join(v.vals, ",")
+}
+
+// Set add the value
+func (v *stringsValue) Set(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			v.vals = append(v.vals, item)
+		}
+	}
+	return nil
+}
+
+// Get the value list
+func (v *stringsValue) Get() interface{} {
+	return v.vals
+}
+
+// mapValue the repeatable flag value, the value format is "key=value"
+type mapValue struct {
+	def  map[string]string
+	vals map[string]string
+}
+
+// String get the flag value string
+func (v *mapValue) String() string {
+	mp := v.vals
+	if mp == nil {
+		mp = v.def
+	}
+
+	pairs := make([]string, 0, len(mp))
+	for k, val := range mp {
+		pairs = append(pairs, k+"="+val)
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set add the key value pair
+func (v *mapValue) Set(s string) error {
+	pos := strings.IndexByte(s, '=')
+	if pos < 1 {
+		return fmt.Errorf("invalid value %q, the format should be key=value", s)
+	}
+
+	if v.vals == nil {
+		v.vals = make(map[string]string)
+	}
+	v.vals[strings.TrimSpace(s[:pos])] = strings.TrimSpace(s[pos+1:])
+	return nil
+}
+
+// Get the value map
+func (v *mapValue) Get() interface{} {
+	return v.vals
+}
+
+// LoadArgs load the key values from the args, returns the rest args.
+func LoadArgs(args []string) ([]string, error) { return dc.LoadArgs(args) }
+
+// LoadArgs load the key values from the cli args, allow override any key without define flags.
+// the other args are returned untouched, for the app's own parser. the args after "--" are not parsed.
+//
+//	--set key=value       set the value, will convert to the type of the exists value.
+//	--set-file key=path   set the value as the file contents.
+//	--set-json key=json   set the value decoded from the JSON string.
+//
+// Usage:
+//
+//	// app --set db.port=5433 --set-json servers='["a", "b"]' --verbose
+//	rest, err := c.LoadArgs(os.Args[1:]) // rest: []string{"--verbose"}
+func (c *Config) LoadArgs(args []string) (rest []string, err error) {
+	c = c.loadIn(LayerFlags)
+
+	var loaded []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value := strings.TrimLeft(arg, "-"), ""
+		hasValue := false
+		if pos := strings.IndexByte(name, '='); pos > 0 {
+			name, value, hasValue = name[:pos], name[pos+1:], true
+		}
+
+		if !strings.HasPrefix(arg, "-") || (name != "set" && name != "set-file" && name != "set-json") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return rest, fmt.Errorf("config: missing the value for the flag %q", arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		var key string
+		if key, err = c.setArg(name, value); err != nil {
+			return
+		}
+		loaded = append(loaded, key)
+	}
+
+	if len(loaded) > 0 {
+		c.notify(OnLoadData, c.layerFor(LayerFlags), loaded...)
+	}
+	return
+}
+
+// set the value by the arg flag name and value "key=value"
+func (c *Config) setArg(name, pair string) (key string, err error) {
+	pos := strings.IndexByte(pair, '=')
+	if pos < 1 {
+		return "", fmt.Errorf("config: invalid value %q for the flag --%s, the format should be key=value", pair, name)
+	}
+
+	key, str := strings.TrimSpace(pair[:pos]), pair[pos+1:]
+
+	var val interface{}
+	switch name {
+	case "set-file":
+		bts, err := ioutil.ReadFile(str)
+		if err != nil {
+			return "", err
+		}
+		val = string(bts)
+	case "set-json":
+		if err = json.Unmarshal([]byte(str), &val); err != nil {
+			return "", fmt.Errorf("config: invalid JSON value for the key %q: %s", key, err.Error())
+		}
+	default: // set
+		if exist, ok := c.GetValue(key); ok {
+			val = convertLike(str, exist)
+		} else {
+			val = inferValue(str)
+		}
+	}
+
+	return key, c.Set(key, val)
+}
+
+// infer the value type from the string. eg: "true" => true, "12" => 12, "1.5" => 1.5
+//
+// The number is inferred only if it formats back to the same string, so "1.10", "007"
+// and "1e3" are kept as string.
+func inferValue(str string) interface{} {
+	if i, err := strconv.Atoi(str); err == nil && strconv.Itoa(i) == str {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(str, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == str {
+		return f
+	}
+
+	if b, err := strconv.ParseBool(str); err == nil && (str == "true" || str == "false") {
+		return b
+	}
+	return str
+}
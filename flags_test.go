@@ -0,0 +1,206 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_LoadFlagSet(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost", "port": 3306}, "labels": {"app": "demo"}}`))
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	// the flag owned by app
+	verbose := fs.Bool("verbose", false, "verbose output")
+	fs.String("log-file", "", "the log file")
+
+	err := c.LoadFlagSet(fs, []string{
+		"--db-host", "127.0.0.1",
+		"--db-port", "3307",
+		"--rate=0.5",
+		"--timeout", "1m30s",
+		"--debug",
+		"--tag", "a", "--tag", "b,c",
+		"--label", "env=prod", "--label", "team = dev",
+		"--log-file", "app.log",
+		"--verbose",
+		"arg0",
+	},
+		FlagSpec{Key: "db.host", Usage: "the database host"},
+		FlagSpec{Key: "db.port", Type: "int"},
+		FlagSpec{Key: "db.user", Usage: "not given"},
+		FlagSpec{Key: "rate", Type: "float"},
+		FlagSpec{Key: "timeout", Type: "duration"},
+		FlagSpec{Key: "debug", Type: "bool"},
+		FlagSpec{Key: "tags", Name: "tag", Type: "strings"},
+		FlagSpec{Key: "labels", Name: "label", Type: "map"},
+		FlagSpec{Key: "log.file", Name: "log-file"},
+	)
+	is.NoError(err)
+
+	is.Equal("127.0.0.1", c.String("db.host"))
+	is.Equal(3307, c.Get("db.port"))
+	is.False(c.Exists("db.user"))
+	is.Equal(0.5, c.Get("rate"))
+	// the duration is stored as string
+	is.Equal("1m30s", c.Get("timeout"))
+	d, err := time.ParseDuration(c.String("timeout"))
+	is.NoError(err)
+	is.Equal(90*time.Second, d)
+	is.Equal(true, c.Get("debug"))
+	is.Equal([]string{"a", "b", "c"}, c.Get("tags"))
+	is.Equal(map[string]string{"app": "demo", "env": "prod", "team": "dev"}, c.StringMap("labels"))
+	is.Equal("app.log", c.String("log.file"))
+	is.True(*verbose)
+	is.Equal([]string{"arg0"}, fs.Args())
+
+	is.Equal(LayerFlags, c.Origin("db.host"))
+	is.Equal(LayerFiles, c.Origin("labels.app"))
+
+	is.Len(events, 1)
+	is.Equal(LayerFlags, events[0].Source)
+	is.Equal([]string{"db.host", "db.port", "debug", "labels", "log.file", "rate", "tags", "timeout"}, events[0].Keys)
+
+	// error
+	is.Error(c.LoadFlagSet(flag.NewFlagSet("app", flag.ContinueOnError), nil, FlagSpec{Key: " "}))
+
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(new(bytes.Buffer))
+	is.Error(c.LoadFlagSet(fs, []string{"--label", "invalid"}, FlagSpec{Key: "labels", Name: "label", Type: "map"}))
+}
+
+func TestConfig_LoadFlagSet_usage(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost"}, "tags": ["a", "b"], "labels": {"env": "dev"}, "timeout": "10s"}`))
+
+	buf := new(bytes.Buffer)
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(buf)
+
+	is.NoError(c.LoadFlagSet(fs, nil,
+		FlagSpec{Key: "db.host", Usage: "the database `host`"},
+		FlagSpec{Key: "tags", Type: "strings", Usage: "the tags, repeatable"},
+		FlagSpec{Key: "labels", Type: "map", Usage: "the labels, format: key=value"},
+		FlagSpec{Key: "timeout", Type: "duration", Usage: "the timeout"},
+	))
+	fs.PrintDefaults()
+
+	usage := buf.String()
+	is.Contains(usage, "-db-host host")
+	is.Contains(usage, "the database host (default \"localhost\")")
+	is.Contains(usage, "the tags, repeatable (default a,b)")
+	is.Contains(usage, "the labels, format: key=value (default env=dev)")
+	is.Contains(usage, "the timeout (default 10s)")
+
+	// the flag defaults are not recorded as accessed
+	c = NewWithOptions("test", TrackAccess)
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost", "port": 3306}, "timeout": "10s"}`))
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	is.NoError(c.LoadFlagSet(fs, nil,
+		FlagSpec{Key: "db.host"},
+		FlagSpec{Key: "db.port", Type: "int"},
+		FlagSpec{Key: "timeout", Type: "duration"},
+	))
+	is.Equal("3306", fs.Lookup("db-port").DefValue)
+	is.Equal([]string{"db.host", "db.port", "timeout"}, c.AccessReport().Unused)
+
+	// package level
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	is.NoError(LoadFlagSet(fs, []string{"--test-flag-key", "val"}, FlagSpec{Key: "test.flag.key"}))
+	is.Equal("val", String("test.flag.key"))
+	ClearAll()
+}
+
+func TestConfig_LoadArgs(t *testing.T) {
+	is := assert.New(t)
+
+	certFile := filepath.Join(t.TempDir(), "cert.pem")
+	is.NoError(ioutil.WriteFile(certFile, []byte("CERT CONTENT"), 0600))
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost", "port": 3306, "ssl": false}, "name": "app"}`))
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	rest, err := c.LoadArgs([]string{
+		"--set", "db.port=5433",
+		"--verbose",
+		"-set=db.ssl=true",
+		"--set", "db.user=admin",
+		"--set=retry=3",
+		"--set=ratio=0.5",
+		"--set", "version=1.10",
+		"--set", "code=007",
+		"--set", "enable=true",
+		"--set", "query=a=b",
+		"--set-file", "tls.cert=" + certFile,
+		"--set-json", `servers=[{"host": "a"}, {"host": "b"}]`,
+		"--name", "other",
+		"--", "--set", "name=not-set",
+	})
+	is.NoError(err)
+	is.Equal([]string{"--verbose", "--name", "other", "--", "--set", "name=not-set"}, rest)
+
+	is.Equal(float64(5433), c.Get("db.port"))
+	is.Equal(true, c.Get("db.ssl"))
+	is.Equal("admin", c.Get("db.user"))
+	is.Equal(3, c.Get("retry"))
+	is.Equal(0.5, c.Get("ratio"))
+	// keep the string if the number cannot round trip
+	is.Equal("1.10", c.Get("version"))
+	is.Equal("007", c.Get("code"))
+	is.Equal(true, c.Get("enable"))
+	is.Equal("a=b", c.Get("query"))
+	is.Equal("CERT CONTENT", c.String("tls.cert"))
+	is.Equal([]interface{}{
+		map[string]interface{}{"host": "a"},
+		map[string]interface{}{"host": "b"},
+	}, c.Get("servers"))
+	is.Equal("app", c.String("name"))
+	is.Equal(LayerFlags, c.Origin("db.port"))
+
+	is.Len(events, 1)
+	is.Equal(LayerFlags, events[0].Source)
+	is.Len(events[0].Keys, 11)
+
+	// no set args
+	rest, err = c.LoadArgs([]string{"-v", "file.txt"})
+	is.NoError(err)
+	is.Equal([]string{"-v", "file.txt"}, rest)
+	is.Len(events, 1)
+
+	// errors
+	_, err = c.LoadArgs([]string{"--set"})
+	is.Error(err)
+	_, err = c.LoadArgs([]string{"--set", "invalid"})
+	is.Error(err)
+	_, err = c.LoadArgs([]string{"--set-json", "key={invalid"})
+	is.Error(err)
+	_, err = c.LoadArgs([]string{"--set-file", "key=not-exists.pem"})
+	is.Error(err)
+
+	// package level
+	rest, err = LoadArgs([]string{"--set", "test.args.key=val"})
+	is.NoError(err)
+	is.Empty(rest)
+	is.Equal("val", String("test.args.key"))
+	ClearAll()
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultIncludeKey the default reserved key for include other files. see EnableInclude()
+const DefaultIncludeKey = "_include"
+
+// EnableInclude enable include other files by the key "_include" in the config file
+func EnableInclude(opts *Options) { opts.IncludeKey = DefaultIncludeKey }
+
+// WithIncludeKey set the reserved key for include other files in the config file.
+//
+// The value can be a file path, a glob pattern, or a list of them. the item can be
+// a map for mount the file data under a key prefix, or allow the file is not exists.
+//
+//	# app.yaml
+//	_include:
+//	  - db.yml
+//	  - conf.d/*.toml
+//	  - { file: cache.json, prefix: cache.redis, optional: true }
+//
+// The paths are relative to the including file, and the included files are loaded
+// before the including file, so the including file data will override them.
+func WithIncludeKey(key string) func(*Options) {
+	return func(opts *Options) {
+		opts.IncludeKey = key
+	}
+}
+
+// includeItem the include item in the config file
+type includeItem struct {
+	file     string
+	prefix   string
+	optional bool
+}
+
+// load the files included by the documents, returns the documents without the include key.
+func (c *Config) loadIncludes(file string, docs []map[string]interface{}) ([]map[string]interface{}, error) {
+	key := c.opts.IncludeKey
+
+	var items []includeItem
+	out := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		val, ok := doc[key]
+		if !ok {
+			out = append(out, doc)
+			continue
+		}
+
+		list, err := parseIncludes(val)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid %q value in the file %q: %s", key, file, err.Error())
+		}
+		items = append(items, list...)
+
+		// remove the include key from the merged data, the file data still has it for write back changes.
+		data := make(map[string]interface{}, len(doc))
+		for k, v := range doc {
+			if k != key {
+				data[k] = v
+			}
+		}
+		out = append(out, data)
+	}
+
+	if len(items) == 0 {
+		return out, nil
+	}
+
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// load the included files by a handle with the including file in the stack
+	ctx := c.ctx
+	ctx.incStack = append(append([]string(nil), c.ctx.incStack...), absFile)
+	h := c.handle(c.prefix, ctx)
+
+	dir := filepath.Dir(absFile)
+	for _, item := range items {
+		if err = h.loadInclude(dir, item); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// load the included file(s) by the item, the path is relative to the dir.
+// the file data is mounted under the item prefix, it's relative to the prefix of the including file.
+func (c *Config) loadInclude(dir string, item includeItem) error {
+	path := expandPath(item.file)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	files := []string{path}
+	if strings.ContainsAny(item.file, "*?[") {
+		var err error
+		if files, err = filepath.Glob(path); err != nil {
+			return fmt.Errorf("config: invalid include pattern %q: %s", item.file, err.Error())
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) && item.optional {
+			return nil
+		}
+		return fmt.Errorf("config: the included file %q not exists", path)
+	}
+
+	for _, file := range files {
+		for i, included := range c.ctx.incStack {
+			if included == file {
+				chain := append(append([]string{}, c.ctx.incStack[i:]...), file)
+				return fmt.Errorf("config: include cycle detected: %s", strings.Join(chain, " -> "))
+			}
+		}
+
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			continue
+		}
+
+		if err := c.loadMounted(file, item.prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse the include value to items. allow: string, list of string or map
+func parseIncludes(val interface{}) ([]includeItem, error) {
+	switch typVal := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []includeItem{{file: typVal}}, nil
+	case []string:
+		items := make([]includeItem, 0, len(typVal))
+		for _, file := range typVal {
+			items = append(items, includeItem{file: file})
+		}
+		return items, nil
+	case []interface{}:
+		var items []includeItem
+		for _, v := range typVal {
+			list, err := parseIncludes(v)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, list...)
+		}
+		return items, nil
+	}
+
+	mp := toStringMap(val)
+	if mp == nil {
+		return nil, fmt.Errorf("unsupported value type %T", val)
+	}
+
+	item := includeItem{}
+	item.file, _ = mp["file"].(string)
+	item.prefix, _ = mp["prefix"].(string)
+	item.optional, _ = mp["optional"].(bool)
+	if item.file == "" {
+		return nil, errors.New(`the include item must have the "file" field`)
+	}
+	return []includeItem{item}, nil
+}
+
+// mount the documents under the key prefix. eg: "cache.redis"
+func mountDocs(prefix string, docs []map[string]interface{}, sep byte) []map[string]interface{} {
+	if prefix == "" {
+		return docs
+	}
+
+	keys := strings.Split(prefix, string(sep))
+	out := make([]map[string]interface{}, 0, len(docs))
+	for _, data := range docs {
+		for i := len(keys) - 1; i >= 0; i-- {
+			data = map[string]interface{}{keys[i]: data}
+		}
+		out = append(out, data)
+	}
+	return out
+}
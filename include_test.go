@@ -0,0 +1,181 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+	}
+}
+
+func TestConfig_include(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"app.json": `{
+	"_include": ["db.yml", "conf.d/*.json", {"file": "redis.json", "prefix": "cache.redis"}, {"file": "none.json", "optional": true}],
+	"name": "app",
+	"db": {"port": 5432}
+}`,
+		"db.yml":           `{"db": {"host": "localhost", "port": 3306}}`,
+		"conf.d/a.json":    `{"log": {"level": "info", "file": "app.log"}}`,
+		"conf.d/b.json":    `{"log": {"level": "debug"}, "_include": "../sub/c.json"}`,
+		"conf.d/skip.yaml": `{"skip": true}`,
+		"sub/c.json":       `{"tags": ["c"]}`,
+		"redis.json":       `{"host": "127.0.0.1", "_include": {"file": "pool.json", "prefix": "pool"}}`,
+		"pool.json":        `{"size": 10}`,
+	})
+
+	c := NewWithOptions("test", EnableInclude)
+	c.AddDriver(yamlDriver)
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	is.NoError(c.LoadFiles(filepath.Join(dir, "app.json")))
+
+	is.Equal("app", c.String("name"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(5432, c.Int("db.port"))
+	is.Equal("debug", c.String("log.level"))
+	is.Equal("app.log", c.String("log.file"))
+	is.Equal([]string{"c"}, c.Strings("tags"))
+	is.Equal("127.0.0.1", c.String("cache.redis.host"))
+	is.Equal(10, c.Int("cache.redis.pool.size"))
+	is.False(c.Exists("skip"))
+	is.False(c.Exists("_include"))
+	is.False(c.Exists("cache.redis._include"))
+
+	is.Equal([]string{
+		filepath.Join(dir, "db.yml"),
+		filepath.Join(dir, "conf.d/a.json"),
+		filepath.Join(dir, "sub/c.json"),
+		filepath.Join(dir, "conf.d/b.json"),
+		filepath.Join(dir, "pool.json"),
+		filepath.Join(dir, "redis.json"),
+		filepath.Join(dir, "app.json"),
+	}, c.LoadedFiles())
+
+	is.Len(events, 7)
+	is.Equal([]string{"cache"}, events[4].Keys)
+	is.Equal([]string{"db", "name"}, events[6].Keys)
+
+	// write back the changes to the included files
+	is.NoError(c.Set("cache.redis.host", "redis.local"))
+	is.NoError(c.Set("cache.redis.pool.size", 20))
+	is.NoError(c.Set("log.file", "other.log"))
+	is.NoError(c.SaveChanges())
+
+	c2 := NewWithOptions("test", EnableInclude)
+	c2.AddDriver(yamlDriver)
+	is.NoError(c2.LoadFiles(filepath.Join(dir, "app.json")))
+	is.Equal("redis.local", c2.String("cache.redis.host"))
+	is.Equal(20, c2.Int("cache.redis.pool.size"))
+	is.Equal("other.log", c2.String("log.file"))
+
+	bts, err := ioutil.ReadFile(filepath.Join(dir, "redis.json"))
+	is.NoError(err)
+	is.Contains(string(bts), `"_include"`)
+	is.NotContains(string(bts), `"cache"`)
+
+	// disabled by default
+	c = New("test")
+	c.AddDriver(yamlDriver)
+	is.NoError(c.LoadFiles(filepath.Join(dir, "app.json")))
+	is.True(c.Exists("_include"))
+	is.False(c.Exists("db.host"))
+}
+
+func TestConfig_include_errors(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.json":       `{"@import": "b.json"}`,
+		"b.json":       `{"@import": ["sub/c.json"]}`,
+		"sub/c.json":   `{"@import": "../a.json"}`,
+		"self.json":    `{"@import": "self.json"}`,
+		"missing.json": `{"@import": "not-exists.json"}`,
+		"invalid.json": `{"@import": 123}`,
+		"no-file.json": `{"@import": [{"prefix": "a"}]}`,
+		"bad.json":     `{"@import": "bad.xml"}`,
+		"bad.xml":      `<xml></xml>`,
+	})
+
+	c := NewWithOptions("test", WithIncludeKey("@import"))
+
+	err := c.LoadFiles(filepath.Join(dir, "a.json"))
+	is.Error(err)
+	is.Contains(err.Error(), "include cycle detected")
+	is.True(strings.HasSuffix(err.Error(), strings.Join([]string{
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "b.json"),
+		filepath.Join(dir, "sub/c.json"),
+		filepath.Join(dir, "a.json"),
+	}, " -> ")))
+	is.Empty(c.ctx.incStack)
+
+	is.Error(c.LoadFiles(filepath.Join(dir, "self.json")))
+
+	err = c.LoadFiles(filepath.Join(dir, "missing.json"))
+	is.Error(err)
+	is.Contains(err.Error(), "not-exists.json")
+
+	is.Error(c.LoadFiles(filepath.Join(dir, "invalid.json")))
+	is.Error(c.LoadFiles(filepath.Join(dir, "no-file.json")))
+	is.Error(c.LoadFiles(filepath.Join(dir, "bad.json")))
+}
+
+func TestConfig_include_concurrent(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.json":     `{"_include": "b.json", "a": true}`,
+		"b.json":     `{"b": true}`,
+		"redis.json": `{"_include": {"file": "b.json", "prefix": "pool"}}`,
+	})
+
+	c := NewWithOptions("test", EnableInclude)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			is.NoError(c.LoadFiles(filepath.Join(dir, "redis.json")))
+		}
+	}()
+
+	// the include stack and prefix are not shared with other callers
+	for i := 0; i < 20; i++ {
+		is.NoError(c.LoadFiles(filepath.Join(dir, "a.json")))
+	}
+	<-done
+
+	is.True(c.Bool("a"))
+	is.True(c.Bool("b"))
+	is.True(c.Bool("pool.b"))
+	is.Equal([]string{"a", "b", "pool"}, topKeys(c.Data()))
+}
+
+func TestMountDocs(t *testing.T) {
+	is := assert.New(t)
+
+	docs := []map[string]interface{}{{"host": "a"}}
+	is.Equal(docs, mountDocs("", docs, '.'))
+	is.Equal([]map[string]interface{}{
+		{"cache": map[string]interface{}{"redis": map[string]interface{}{"host": "a"}}},
+	}, mountDocs("cache.redis", docs, '.'))
+}
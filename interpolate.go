@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// resolve the references in all values of the data. will record error on resolve fail.
+//
+// It's used for dump or read the whole data, so the referenced keys are not recorded as accessed.
+func (c *Config) resolveData(data map[string]interface{}, decrypt bool) map[string]interface{} {
+	r := c.newRefResolver(decrypt)
+	val := r.resolve("", data)
+	if r.err != nil {
+		c.addError(r.err)
+	}
+	return val.(map[string]interface{})
+}
+
+// find value by the key path, and the parent value is a reference. eg: "srv: ${server}", get "srv.host"
+func (c *Config) getValueByRefs(key string) (val interface{}, ok bool, err error) {
+	sep := string(c.opts.Delimiter)
+	keys := strings.Split(formatKey(key, sep), sep)
+
+	for i := len(keys) - 1; i > 0; i-- {
+		parent, found := findByKeys(c.data, keys[:i])
+		if !found {
+			continue
+		}
+
+		str, isStr := parent.(string)
+		if !isStr || !strings.Contains(str, "${") {
+			return
+		}
+
+		parent, err = c.resolveValue(strings.Join(keys[:i], sep), parent, true)
+		if sub := toStringMap(parent); sub != nil {
+			val, ok = findByKeys(sub, keys[i:])
+		}
+		return
+	}
+	return
+}
+
+// resolve the references and decrypt the encrypted values, key is the key path of the value.
+//
+// Reference syntax:
+//
+//	"${server.host}"         // reference other config key
+//	"${server.port|8080}"    // with default value
+//	"$${server.host}"        // escaped, will output "${server.host}"
+//	"${file:/path/to/file}"  // resolve value by the resolver. see Config.AddResolver()
+//
+// If decrypt is true, will decrypt the encrypted values. see Config.SetKeyProvider()
+//
+// If there are some errors, will return the first error and the value resolved as much as possible.
+func (c *Config) resolveValue(key string, val interface{}, decrypt bool) (interface{}, error) {
+	r := c.newRefResolver(decrypt)
+	r.track = c.opts.TrackAccess
+	if key != "" {
+		r.stack = []string{key}
+	}
+
+	newVal := r.resolve(key, val)
+	return newVal, r.err
+}
+
+func (c *Config) newRefResolver(decrypt bool) *refResolver {
+	return &refResolver{
+		c:       c,
+		sep:     string(c.opts.Delimiter),
+		refs:    c.opts.ParseRefs,
+		decrypt: decrypt && c.opts.KeyProvider != nil,
+	}
+}
+
+// refResolver for resolve references in the config values
+type refResolver struct {
+	c   *Config
+	sep string
+	err error
+	// the keys being resolved, for check circular reference
+	stack []string
+	// resolve the references in string values
+	refs bool
+	// only resolve the "${scheme:arg}" by resolvers. see Config.AddResolver()
+	onlySchemes bool
+	// decrypt the encrypted values
+	decrypt bool
+	// record the referenced keys as accessed. see Options.TrackAccess
+	track bool
+}
+
+func (r *refResolver) addError(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *refResolver) subKey(key, sub string) string {
+	if key == "" {
+		return sub
+	}
+	return key + r.sep + sub
+}
+
+// resolve value, map and slice value will be copied.
+func (r *refResolver) resolve(key string, val interface{}) interface{} {
+	switch typVal := val.(type) {
+	case string:
+		if r.decrypt && IsEncrypted(typVal) {
+			return r.decryptValue(key, typVal)
+		}
+
+		if r.refs {
+			return r.resolveString(typVal)
+		}
+		return typVal
+	case map[string]interface{}:
+		mp := make(map[string]interface{}, len(typVal))
+		for k, v := range typVal {
+			mp[k] = r.resolve(r.subKey(key, k), v)
+		}
+		return mp
+	case map[interface{}]interface{}:
+		mp := make(map[interface{}]interface{}, len(typVal))
+		for k, v := range typVal {
+			mp[k] = r.resolve(r.subKey(key, strutil.MustString(k)), v)
+		}
+		return mp
+	case []interface{}:
+		arr := make([]interface{}, len(typVal))
+		for i, v := range typVal {
+			arr[i] = r.resolve(r.subKey(key, strutil.MustString(i)), v)
+		}
+		return arr
+	case map[string]string:
+		mp := make(map[string]string, len(typVal))
+		for k, v := range typVal {
+			mp[k] = strutil.MustString(r.resolve(r.subKey(key, k), v))
+		}
+		return mp
+	case []string:
+		arr := make([]string, len(typVal))
+		for i, v := range typVal {
+			arr[i] = strutil.MustString(r.resolve(r.subKey(key, strutil.MustString(i)), v))
+		}
+		return arr
+	}
+	return val
+}
+
+// resolve references in the string. if the string is only one reference, will return the raw referenced value.
+func (r *refResolver) resolveString(str string) interface{} {
+	if !strings.Contains(str, "${") {
+		return str
+	}
+
+	// only one reference. eg: "${db.port}"
+	if strings.HasPrefix(str, "${") && strings.IndexByte(str, '}') == len(str)-1 {
+		if val, ok := r.evalExpr(str[2 : len(str)-1]); ok {
+			return val
+		}
+		return str
+	}
+
+	var sb strings.Builder
+	for {
+		pos := strings.Index(str, "${")
+		if pos < 0 {
+			break
+		}
+
+		// escaped by "$${"
+		if pos > 0 && str[pos-1] == '$' {
+			sb.WriteString(str[:pos-1])
+			sb.WriteString("${")
+			str = str[pos+2:]
+			continue
+		}
+
+		end := strings.IndexByte(str[pos:], '}')
+		if end < 0 { // not closed
+			break
+		}
+
+		sb.WriteString(str[:pos])
+		expr := str[pos : pos+end+1]
+		if val, ok := r.evalExpr(expr[2 : len(expr)-1]); ok {
+			sb.WriteString(strutil.MustString(val))
+		} else {
+			sb.WriteString(expr)
+		}
+		str = str[pos+end+1:]
+	}
+
+	sb.WriteString(str)
+	return sb.String()
+}
+
+// eval the reference expression. eg: "server.host", "server.port|8080", "file:/path/to/file"
+func (r *refResolver) evalExpr(expr string) (val interface{}, ok bool) {
+	name, def := expr, ""
+	hasDef := strings.IndexByte(expr, '|') > -1
+	if hasDef {
+		nodes := strings.SplitN(expr, "|", 2)
+		name, def = strings.TrimSpace(nodes[0]), strings.TrimSpace(nodes[1])
+	}
+
+	// resolve by the resolver. eg: "${file:/path/to/file}"
+	if scheme, arg, isExpr := parseResolverExpr(name); isExpr {
+		if rs := r.c.getResolver(scheme); rs != nil {
+			str, err := rs.resolve(arg)
+			if err == nil {
+				return str, true
+			}
+
+			if hasDef {
+				return def, true
+			}
+
+			r.addError(fmt.Errorf("config: resolve the value %q by %q resolver error: %v", name, scheme, err))
+			return nil, false
+		}
+	}
+
+	if r.onlySchemes {
+		return nil, false
+	}
+
+	if name = formatKey(name, r.sep); name == "" {
+		return
+	}
+
+	if val, ok = findByKeys(r.c.data, strings.Split(name, r.sep)); ok {
+		return r.resolveRef(name, val)
+	}
+
+	// fallback to read ENV value
+	if r.c.opts.ParseEnv {
+		if val, ok = os.LookupEnv(name); ok {
+			return
+		}
+	}
+
+	if hasDef {
+		return def, true
+	}
+
+	r.addError(fmt.Errorf("config: the referenced key %q is not exists", name))
+	return nil, false
+}
+
+// resolve the referenced key value, will check circular reference.
+func (r *refResolver) resolveRef(name string, val interface{}) (interface{}, bool) {
+	for _, key := range r.stack {
+		if key == name {
+			chain := strings.Join(append(r.stack, name), " -> ")
+			r.addError(fmt.Errorf("config: circular reference of the key %q: %s", name, chain))
+			return nil, false
+		}
+	}
+
+	if r.track {
+		r.c.recordAccess(name)
+	}
+
+	r.stack = append(r.stack, name)
+	val = r.resolve(name, val)
+	r.stack = r.stack[:len(r.stack)-1]
+	return val, true
+}
+
+// decrypt the encrypted value, will return the raw value on decrypt fail.
+func (r *refResolver) decryptValue(key, str string) interface{} {
+	plain, err := r.c.DecryptValue(str)
+	if err != nil {
+		r.addError(fmt.Errorf("config: decrypt the value of the key %q error: %v", key, err))
+		return str
+	}
+	return plain
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_ParseRefs(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", ParseRefs)
+	err := c.LoadStrings(JSON, `{
+	"server": {"host": "localhost", "port": 8080},
+	"url": "http://${server.host}:${server.port}/api",
+	"port": "${server.port}",
+	"srv": "${server}",
+	"timeout": "${server.timeout|30}",
+	"escaped": "$${server.host} and ${server.host}",
+	"list": ["${server.host}", "${url}"],
+	"invalid": "${noClose"
+}`)
+	is.NoError(err)
+
+	is.Equal("http://localhost:8080/api", c.String("url"))
+	is.Equal(8080, c.Int("port"))
+	is.Equal(float64(8080), c.Get("port"))
+	is.Equal("localhost", c.String("srv.host"))
+	is.Equal("30", c.String("timeout"))
+	is.Equal("${server.host} and localhost", c.String("escaped"))
+	is.Equal([]string{"localhost", "http://localhost:8080/api"}, c.Strings("list"))
+	is.Equal("${noClose", c.String("invalid"))
+	is.NoError(c.Error())
+
+	// map value
+	srv, ok := c.GetValue("srv")
+	is.True(ok)
+	is.Equal("localhost", srv.(map[string]interface{})["host"])
+
+	// Data()
+	is.Equal("http://localhost:8080/api", c.Data()["url"])
+
+	// Structure
+	st := struct {
+		URL  string
+		Port int
+		List []string
+	}{}
+	is.NoError(c.Structure("", &st))
+	is.Equal("http://localhost:8080/api", st.URL)
+	is.Equal(8080, st.Port)
+	is.Equal("localhost", st.List[0])
+
+	// dump
+	buf := new(bytes.Buffer)
+	_, err = c.DumpTo(buf, JSON)
+	is.NoError(err)
+	is.Contains(buf.String(), `"url":"http://localhost:8080/api"`)
+	is.Contains(buf.String(), `"escaped":"${server.host} and localhost"`)
+
+	// value is updated
+	is.NoError(c.Set("server.host", "127.0.0.1"))
+	is.Equal("http://127.0.0.1:8080/api", c.String("url"))
+
+	// view
+	is.Equal("127.0.0.1", c.Sub("list").String("0"))
+}
+
+func TestConfig_ParseRefs_error(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", ParseRefs)
+	err := c.LoadStrings(JSON, `{
+	"name": "${not.exists}",
+	"a": "${b}",
+	"b": "x-${a}",
+	"self": {"url": "${self}"}
+}`)
+	is.NoError(err)
+
+	is.Equal("${not.exists}", c.String("name"))
+	err = c.Error()
+	is.Error(err)
+	is.Contains(err.Error(), `"not.exists"`)
+
+	c.String("a")
+	err = c.Error()
+	is.Error(err)
+	is.Contains(err.Error(), "circular reference")
+	is.Contains(err.Error(), "a -> b -> a")
+
+	st := struct{ URL string }{}
+	err = c.Structure("self", &st)
+	is.Error(err)
+	is.Contains(err.Error(), "circular reference")
+}
+
+func TestConfig_ParseRefs_env(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", ParseRefs, ParseEnv)
+	err := c.LoadStrings(JSON, `{"name": "app", "home": "${APP_TEST_HOME}/${name}"}`)
+	is.NoError(err)
+
+	testutil.MockEnvValue("APP_TEST_HOME", "/home/inhere", func(_ string) {
+		is.Equal("/home/inhere/app", c.String("home"))
+	})
+}
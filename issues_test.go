@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -33,13 +34,29 @@ func TestIssues_37(t *testing.T) {
 	is.NoError(err)
 	dump.Println(c.Data())
 
-	is.Panics(func() {
-		_ = c.LoadStrings(config.Yaml, `
+	// will not panic on the map types are different
+	err = c.LoadStrings(config.Yaml, `
 lang:
   allowed:
     en: "666"
 `)
-	})
+	is.NoError(err)
+	is.Equal("666", c.String("lang.allowed.en"))
+
+	// type conflict will return error
+	c = config.NewWithOptions("test", config.WithMerge(config.MergeOptions{
+		TypeConflict: config.ConflictError,
+	}))
+	c.AddDriver(yaml.Driver)
+	err = c.LoadStrings(config.JSON, `{"lang": {"allowed": {"en": "ddd"}}}`)
+	is.NoError(err)
+
+	err = c.LoadStrings(config.Yaml, `
+lang:
+  allowed: "666"
+`)
+	is.Error(err)
+	is.Equal("ddd", c.String("lang.allowed.en"))
 }
 
 // https://github.com/gookit/config/issues/37
@@ -128,7 +145,7 @@ func TestIssues_59(t *testing.T) {
 	is.NoError(err)
 	dump.Println(c.Data())
 
-	dumpfile := "testdata/issues59.ini"
+	dumpfile := filepath.Join(t.TempDir(), "issues59.ini")
 	out := fsutil.MustCreateFile(dumpfile, 0666, 0666)
 	_, err = c.DumpTo(out, config.Ini)
 	is.NoError(err)
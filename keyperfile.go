@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LayerKeyPerFile the name prefix of the layer for watched key-per-file dir.
+// the layer name is "keyperfile:" + dir, see WatchKeyPerFile()
+const LayerKeyPerFile = "keyperfile"
+
+// the default interval for poll the watched key-per-file dir
+const defaultWatchInterval = 2 * time.Second
+
+// LoadKeyPerFile load the dir which each file is a key
+func LoadKeyPerFile(dir, prefix string) error { return dc.LoadKeyPerFile(dir, prefix) }
+
+// LoadKeyPerFile load the dir which each file is a key, the file name is the key and the
+// file contents is the value. eg: the mounted Kubernetes ConfigMap, Docker secrets "/run/secrets"
+//
+// The "__" and "." in the file name mean nested keys, the trailing newlines in the value are
+// trimmed. the internal files of the ConfigMap, such as "..data", are skipped.
+//
+// Usage:
+//
+//	// /run/secrets/db__password => "secrets.db.password"
+//	err := c.LoadKeyPerFile("/run/secrets", "secrets")
+func (c *Config) LoadKeyPerFile(dir, prefix string) (err error) {
+	data, err := c.readKeyPerFile(dir, prefix)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	return c.mergeDocs([]map[string]interface{}{data})
+}
+
+// WatchKeyPerFile load the key-per-file dir and watch it
+func WatchKeyPerFile(dir, prefix string, interval time.Duration) (func(), error) {
+	return dc.WatchKeyPerFile(dir, prefix, interval)
+}
+
+// WatchKeyPerFile load the key-per-file dir to a dedicated layer after LayerFiles, then poll
+// the dir by the interval, and replace the layer data on the files changed. returns func for stop.
+//
+// The Kubernetes updates the mounted ConfigMap by swap the "..data" symlink atomically, the
+// swap is also detected. the reload error will be recorded, see Config.Error()
+//
+// It's safe to load other sources while watching, the loaders and the reload hold the config lock.
+//
+// Usage:
+//
+//	stop, err := c.WatchKeyPerFile("/etc/app/config", "", 5*time.Second)
+//	defer stop()
+func (c *Config) WatchKeyPerFile(dir, prefix string, interval time.Duration) (func(), error) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	stamp := keyPerFileStamp(dir)
+	data, err := c.readKeyPerFile(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// on the config is a view, the data is mounted under the view prefix.
+	sep := c.opts.Delimiter
+	data = mountDocs(c.prefix, []map[string]interface{}{data}, sep)[0]
+
+	root := c.rootConfig()
+	layer := LayerKeyPerFile + ":" + dir
+	if !c.HasLayer(layer) {
+		if err = c.AddLayer(layer, LayerFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = root.setLayer(layer, data, c.mergeOptions()); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			newStamp := keyPerFileStamp(dir)
+			if newStamp == stamp {
+				continue
+			}
+
+			data, err := c.readKeyPerFile(dir, prefix)
+			if err == nil {
+				data = mountDocs(c.prefix, []map[string]interface{}{data}, sep)[0]
+				err = root.setLayer(layer, data, c.mergeOptions())
+			}
+
+			// retry on next tick if failed
+			if err != nil {
+				root.addError(err)
+				continue
+			}
+			stamp = newStamp
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}, nil
+}
+
+// read the files in the dir to the data map
+func (c *Config) readKeyPerFile(dir, prefix string) (map[string]interface{}, error) {
+	list, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := string(c.opts.Delimiter)
+	prefix = formatKey(prefix, sep)
+
+	data := make(map[string]interface{}, len(list))
+	for _, fi := range list {
+		name := fi.Name()
+		// skip the internal files of the ConfigMap. eg: "..data", "..2024_01_02_15_04_05.123"
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+
+		// the key file maybe a symlink. eg: "db_host -> ..data/db_host"
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		bts, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key := c.fileNameKey(name)
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + sep + key
+		}
+
+		val := strings.TrimRight(string(bts), "\r\n")
+		if err = setValue(data, key, val, c.opts.Delimiter, true); err != nil {
+			return nil, fmt.Errorf("config: cannot set the key %q from the file %q: %s", key, path, err.Error())
+		}
+	}
+	return data, nil
+}
+
+// convert the file name to key path, the "__" and "." mean nested keys.
+func (c *Config) fileNameKey(name string) string {
+	sep := string(c.opts.Delimiter)
+	key := strings.Replace(name, "__", sep, -1)
+	if sep != "." {
+		key = strings.Replace(key, ".", sep, -1)
+	}
+	return formatKey(key, sep)
+}
+
+// get the stamp of the dir files, for check the files changed.
+// the symlink target is included, for detect the "..data" swap.
+func keyPerFileStamp(dir string) string {
+	list, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, fi := range list {
+		path := filepath.Join(dir, fi.Name())
+		line := fi.Name()
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, _ := os.Readlink(path)
+			line += " -> " + target
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			line += fmt.Sprintf(" %d %d", info.Size(), info.ModTime().UnixNano())
+		}
+		lines = append(lines, line)
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
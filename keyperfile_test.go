@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// create the dir layout like the mounted Kubernetes ConfigMap
+func writeConfigMap(t *testing.T, dir, version string, files map[string]string) {
+	is := assert.New(t)
+
+	dataDir := "..2024_01_01_" + version
+	writeTestFiles(t, filepath.Join(dir, dataDir), files)
+
+	// swap the "..data" symlink atomically
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	is.NoError(os.Symlink(dataDir, tmpLink))
+	is.NoError(os.Rename(tmpLink, filepath.Join(dir, "..data")))
+
+	for name := range files {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			is.NoError(os.Symlink(filepath.Join("..data", name), link))
+		}
+	}
+}
+
+func TestConfig_LoadKeyPerFile(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "v1", map[string]string{
+		"db__host":  "localhost\n",
+		"db__port":  "3306\r\n",
+		"log.level": "debug",
+		"cert":      "line1\nline2\n\n",
+		"empty":     "",
+	})
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"name": "app", "db": {"user": "root"}}`))
+
+	var events []*Event
+	c.Subscribe(OnLoadData, func(e *Event) {
+		events = append(events, e)
+	})
+
+	is.NoError(c.LoadKeyPerFile(dir, ""))
+	is.Equal("app", c.String("name"))
+	is.Equal("root", c.String("db.user"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.Equal("debug", c.String("log.level"))
+	is.Equal("line1\nline2", c.String("cert"))
+	is.True(c.Exists("empty"))
+	is.False(c.Exists("..data"))
+	is.Equal(LayerFiles, c.Origin("db.host"))
+
+	is.Len(events, 1)
+	is.Equal([]string{"cert", "db", "empty", "log"}, events[0].Keys)
+
+	// with prefix
+	c = New("test")
+	is.NoError(c.LoadKeyPerFile(dir, "secrets."))
+	is.Equal("localhost", c.String("secrets.db.host"))
+	is.False(c.Exists("db"))
+
+	// custom delimiter
+	c = NewWithOptions("test", Delimiter(':'))
+	is.NoError(c.LoadKeyPerFile(dir, ""))
+	is.Equal("localhost", c.String("db:host"))
+	is.Equal("debug", c.String("log:level"))
+
+	// errors
+	is.Error(c.LoadKeyPerFile(filepath.Join(dir, "not-exists"), ""))
+
+	// package level
+	is.NoError(LoadKeyPerFile(dir, "test.kpf"))
+	is.Equal("localhost", String("test.kpf.db.host"))
+	ClearAll()
+}
+
+func TestConfig_WatchKeyPerFile(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "v1", map[string]string{
+		"db__host": "localhost",
+		"db__port": "3306",
+	})
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "file.host", "user": "root"}}`))
+
+	stop, err := c.WatchKeyPerFile(dir, "", 10*time.Millisecond)
+	is.NoError(err)
+	defer stop()
+
+	layer := LayerKeyPerFile + ":" + dir
+	is.Equal([]string{LayerDefaults, LayerFiles, layer, LayerRemote, LayerEnv, LayerFlags, LayerRuntime}, c.LayerNames())
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal("root", c.String("db.user"))
+	is.Equal(layer, c.Origin("db.host"))
+
+	is.NoError(c.Set("db.port", 5432))
+
+	// swap to the new version, the "db__port" is removed
+	writeConfigMap(t, dir, "v2", map[string]string{
+		"db__host": "db.prod",
+	})
+	is.NoError(os.Remove(filepath.Join(dir, "db__port")))
+
+	is.Eventually(func() bool {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		host, _ := findByKeys(c.data, []string{"db", "host"})
+		return host == "db.prod"
+	}, time.Second, 10*time.Millisecond)
+
+	// the runtime value is kept
+	is.Equal(5432, c.Int("db.port"))
+	is.Equal(map[string]interface{}{"db": map[string]interface{}{"host": "db.prod"}}, c.LayerData(layer))
+
+	stop()
+	stop()
+
+	// errors
+	_, err = c.WatchKeyPerFile(filepath.Join(dir, "not-exists"), "", 0)
+	is.Error(err)
+	is.Len(c.LayerNames(), 7)
+}
+
+func TestConfig_WatchKeyPerFile_loadData(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "v1", map[string]string{"db__host": "localhost"})
+
+	c := New("test")
+	stop, err := c.WatchKeyPerFile(dir, "", time.Millisecond)
+	is.NoError(err)
+	defer stop()
+
+	layer := LayerKeyPerFile + ":" + dir
+	// load other sources while the watcher reloading, check by the "-race"
+	for i := 0; i < 20; i++ {
+		if i == 10 {
+			writeConfigMap(t, dir, "v2", map[string]string{"db__host": "db.prod"})
+		}
+
+		is.NoError(c.LoadStrings(JSON, fmt.Sprintf(`{"db": {"port": %d}}`, i)))
+		is.NoError(c.LoadData(map[string]interface{}{"name": "app"}))
+		is.NoError(c.LoadKeyPerFile(dir, "kpf"))
+		is.True(c.HasLayer(layer))
+		time.Sleep(time.Millisecond)
+	}
+
+	is.Eventually(func() bool {
+		host, _ := findByKeys(c.LayerData(layer), []string{"db", "host"})
+		return host == "db.prod"
+	}, time.Second, 10*time.Millisecond)
+	is.Equal(19, c.Int("db.port"))
+}
+
+func TestConfig_fileNameKey(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.Equal("db.host", c.fileNameKey("db__host"))
+	is.Equal("db.host", c.fileNameKey("db.host"))
+	is.Equal("db_host", c.fileNameKey("db_host"))
+	is.Equal("a.b.c", c.fileNameKey("a__b.c"))
+
+	c = NewWithOptions("test", Delimiter('/'))
+	is.Equal("a/b/c", c.fileNameKey("a__b.c"))
+}
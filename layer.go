@@ -0,0 +1,446 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// There are built-in layer names, the config data is merged from layers.
+// ordered by priority from low to high.
+const (
+	LayerDefaults = "defaults"
+	LayerFiles    = "files"
+	LayerRemote   = "remote"
+	LayerEnv      = "env"
+	LayerFlags    = "flags"
+	LayerRuntime  = "runtime"
+)
+
+// DefaultLayers the default layer names, ordered by priority from low to high.
+//
+// Sources are loaded to the layers:
+//   - LoadFiles, LoadSources, LoadStrings, LoadData, LoadKeyPerFile: LayerFiles
+//   - WatchKeyPerFile: the layer "keyperfile:<dir>" after LayerFiles
+//   - LoadRemote: LayerRemote
+//   - LoadOSEnv, LoadEnvPrefix: LayerEnv
+//   - LoadFlags, LoadFlagSet: LayerFlags
+//   - Set: LayerRuntime
+var DefaultLayers = []string{LayerDefaults, LayerFiles, LayerRemote, LayerEnv, LayerFlags, LayerRuntime}
+
+// loadCtx the context for load data through a handle of the config, it's not shared
+// by other callers. see UseLayer(), MergeWith()
+type loadCtx struct {
+	// the target layer, empty for use the default layer of the loader
+	layer string
+	// the merge options, nil for use the default options
+	merge *MergeOptions
+	// the stack of the including files, for detect the include cycle. see IncludeKey
+	incStack []string
+}
+
+// LayerNames get layer names, ordered by priority from low to high.
+func (c *Config) LayerNames() []string {
+	if c.root != nil {
+		return c.root.LayerNames()
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.layerOrder()...)
+}
+
+// HasLayer check the layer name exists
+func (c *Config) HasLayer(name string) bool {
+	if c.root != nil {
+		return c.root.HasLayer(name)
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.layerIndex(name) > -1
+}
+
+// AddLayer add a new layer after the given layer name.
+// if after is empty, will add it as the highest priority layer.
+//
+// Usage:
+//
+//	c.AddLayer("secrets", config.LayerFiles)
+func (c *Config) AddLayer(name, after string) error {
+	// the layer names are shared by the views
+	if c.root != nil {
+		return c.root.AddLayer(name, after)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.layerIndex(name) > -1 {
+		return fmt.Errorf("config: the layer %q has been exists", name)
+	}
+
+	names := c.layerOrder()
+	if after == "" {
+		c.layerNames = append(append([]string(nil), names...), name)
+		return nil
+	}
+
+	idx := c.layerIndex(after)
+	if idx < 0 {
+		return fmt.Errorf("config: the layer %q is not exists", after)
+	}
+
+	newNames := make([]string, 0, len(names)+1)
+	newNames = append(newNames, names[:idx+1]...)
+	newNames = append(newNames, name)
+	c.layerNames = append(newNames, names[idx+1:]...)
+	return nil
+}
+
+// LayerData get the data of the layer. NOTICE: don't modify the returned data.
+// on the config is a view, will return the data under the view prefix.
+func (c *Config) LayerData(name string) map[string]interface{} {
+	root := c.rootConfig()
+	root.lock.RLock()
+	defer root.lock.RUnlock()
+
+	if c.prefix == "" {
+		return root.layers[name]
+	}
+
+	val, _ := findByKeys(root.layers[name], strings.Split(c.prefix, string(c.opts.Delimiter)))
+	return toStringMap(val)
+}
+
+// UseLayer load data to the given layer by call fn.
+//
+// The fn is called with a handle of the config, the data loaded or set by the
+// handle will be written to the layer. other callers of the config are not affected.
+//
+// Usage:
+//
+//	err := c.UseLayer(config.LayerRemote, func(c *config.Config) error {
+//		return c.LoadFiles("/path/to/remote-cache.json")
+//	})
+func (c *Config) UseLayer(name string, fn func(c *Config) error) error {
+	if !c.HasLayer(name) {
+		return fmt.Errorf("config: the layer %q is not exists", name)
+	}
+	return fn(c.withLayer(name))
+}
+
+// ReloadLayer clear the layer data, then call fn to load new data to the layer.
+// data in other layers will be kept, eg: reload files will not wipe runtime overrides.
+//
+// Usage:
+//
+//	err := c.ReloadLayer(config.LayerFiles, func(c *config.Config) error {
+//		return c.LoadFiles("app.yml")
+//	})
+func (c *Config) ReloadLayer(name string, fn func(c *Config) error) error {
+	if err := c.checkLayerOp("reload", name); err != nil {
+		return err
+	}
+
+	root := c.rootConfig()
+	root.lock.Lock()
+	delete(root.layers, name)
+	delete(root.layerMerge, name)
+	err := root.rebuildData()
+	root.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return c.UseLayer(name, fn)
+}
+
+// SetLayer replace the whole data of the layer.
+// the merge options for the layer are reset to the current options, see MergeWith()
+func (c *Config) SetLayer(name string, data map[string]interface{}) error {
+	if err := c.checkLayerOp("set", name); err != nil {
+		return err
+	}
+
+	err := c.rootConfig().setLayer(name, data, c.mergeOptions())
+	if err == nil && c.root != nil {
+		c.fireEvent(OnSetData, name)
+	}
+	return err
+}
+
+// replace the whole data of the layer, and record the merge options for the layer.
+func (c *Config) setLayer(name string, data map[string]interface{}, mo *MergeOptions) error {
+	c.lock.Lock()
+	c.ensureLayers()
+	c.layers[name] = deepCopyMap(data)
+	c.setLayerMerge(name, mo)
+	err := c.rebuildData()
+	c.lock.Unlock()
+
+	if err == nil {
+		c.fireEvent(OnSetData, name)
+	}
+	return err
+}
+
+// RemoveLayer remove the whole data of the layer
+func (c *Config) RemoveLayer(name string) error {
+	if c.IsView() {
+		return fmt.Errorf("config: cannot remove the layer %q on the view %q", name, c.prefix)
+	}
+
+	root := c.rootConfig()
+	root.lock.Lock()
+	delete(root.layers, name)
+	delete(root.layerMerge, name)
+	err := root.rebuildData()
+	root.lock.Unlock()
+
+	if err == nil {
+		c.notify(OnSetData, name)
+	}
+	return err
+}
+
+// check the layer exists, and the whole layer operation is not on a view.
+func (c *Config) checkLayerOp(op, name string) error {
+	if c.IsView() {
+		return fmt.Errorf("config: cannot %s the layer %q on the view %q", op, name, c.prefix)
+	}
+
+	if !c.HasLayer(name) {
+		return fmt.Errorf("config: the layer %q is not exists", name)
+	}
+	return nil
+}
+
+// Origin get the name of the highest priority layer that provides the key.
+// will return empty string on key not exists.
+func (c *Config) Origin(key string) string {
+	if c.root != nil {
+		return c.root.Origin(c.viewKey(key))
+	}
+
+	sep := string(c.opts.Delimiter)
+	if key = formatKey(key, sep); key == "" {
+		return ""
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	keys := strings.Split(key, sep)
+	names := c.layerOrder()
+	for i := len(names) - 1; i >= 0; i-- {
+		if _, ok := findByKeys(c.layers[names[i]], keys); ok {
+			return names[i]
+		}
+
+		// the value is from ENV. see AutomaticEnv(), BindEnv()
+		if names[i] == LayerEnv && c.hasEnvBinding() {
+			if _, ok := c.envValue(key); ok {
+				return LayerEnv
+			}
+		}
+	}
+	return ""
+}
+
+/*************************************************************
+ * internal methods for layers
+ *************************************************************/
+
+func (c *Config) layerOrder() []string {
+	if c.layerNames == nil {
+		return DefaultLayers
+	}
+	return c.layerNames
+}
+
+func (c *Config) layerIndex(name string) int {
+	for i, n := range c.layerOrder() {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// get the target layer for load data, if not set by UseLayer(), will return the def layer.
+func (c *Config) layerFor(def string) string {
+	if c.ctx.layer != "" {
+		return c.ctx.layer
+	}
+	return def
+}
+
+// get a handle for load data to the layer. see UseLayer()
+func (c *Config) withLayer(name string) *Config {
+	ctx := c.ctx
+	ctx.layer = name
+	return c.handle(c.prefix, ctx)
+}
+
+// get a handle for load data to the def layer, if the layer is not set by UseLayer().
+func (c *Config) loadIn(def string) *Config {
+	if c.ctx.layer != "" {
+		return c
+	}
+	return c.withLayer(def)
+}
+
+// check there is no data in the layers with higher priority
+func (c *Config) isTopLayer(name string) bool {
+	names := c.layerOrder()
+	for i := c.layerIndex(name) + 1; i < len(names); i++ {
+		if len(c.layers[names[i]]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Config) ensureLayers() {
+	if c.layers == nil {
+		c.layers = make(map[string]map[string]interface{})
+	}
+}
+
+func (c *Config) ensureLayer(name string) map[string]interface{} {
+	c.ensureLayers()
+	layer, ok := c.layers[name]
+	if !ok {
+		layer = make(map[string]interface{})
+		c.layers[name] = layer
+	}
+	return layer
+}
+
+// ensure the layer for set value by key path. if the top item
+// in the data is not a map, copy it to the layer for set by path.
+func (c *Config) seedLayer(name, key string, byPath bool) map[string]interface{} {
+	layer := c.ensureLayer(name)
+	sep := c.opts.Delimiter
+	if !byPath || strings.IndexByte(key, sep) == -1 {
+		return layer
+	}
+
+	topK := key[:strings.IndexByte(key, sep)]
+	if _, ok := layer[topK]; ok {
+		return layer
+	}
+
+	switch item := c.data[topK].(type) {
+	case nil, map[string]interface{}, map[interface{}]interface{}:
+	default:
+		layer[topK] = deepCopyValue(item)
+	}
+	return layer
+}
+
+// merge data to the layer by the options, and update the config data. NOTICE: should hold the c.lock
+func (c *Config) mergeLayer(name string, data map[string]interface{}, mo *MergeOptions) (err error) {
+	sep := string(c.opts.Delimiter)
+
+	c.ensureLayers()
+	prev := c.layers[name]
+	layer := deepCopyMap(prev)
+	if layer == nil {
+		layer = make(map[string]interface{})
+	}
+
+	// keep null value in the layer, for delete key on rebuild data
+	m := &merger{opts: mo, sep: sep, keepNull: true}
+	if err = m.mergeMap(layer, deepCopyMap(data), ""); err != nil {
+		return
+	}
+
+	prevMo := c.layerMerge[name]
+	c.layers[name] = layer
+	c.setLayerMerge(name, mo)
+
+	if !c.isTopLayer(name) {
+		if err = c.rebuildData(); err != nil {
+			c.layers[name] = prev
+			c.setLayerMerge(name, prevMo)
+		}
+		return
+	}
+
+	// merge to a copy, the current data may be in use. eg: dumping by the auto save
+	target := deepCopyMap(c.data)
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+
+	// the layer is top, merge data to the config data directly
+	m = &merger{opts: mo, sep: sep}
+	if err = m.mergeMap(target, deepCopyMap(data), ""); err != nil {
+		c.layers[name] = prev
+		c.setLayerMerge(name, prevMo)
+		return
+	}
+
+	c.data = target
+	return
+}
+
+// rebuild the config data by merge all layers data.
+func (c *Config) rebuildData() error {
+	data, err := c.mergeLayers(func(string) bool { return true })
+	if err == nil {
+		c.data = data
+	}
+	return err
+}
+
+// merge data of the layers with lower priority than the layer.
+func (c *Config) dataBelow(name string) (map[string]interface{}, error) {
+	below := make(map[string]bool)
+	for _, n := range c.layerOrder() {
+		if n == name {
+			break
+		}
+		below[n] = true
+	}
+
+	return c.mergeLayers(func(n string) bool {
+		return below[n]
+	})
+}
+
+// record the merge options for the layer, nil for use the default options.
+func (c *Config) setLayerMerge(name string, mo *MergeOptions) {
+	if mo == nil {
+		delete(c.layerMerge, name)
+		return
+	}
+
+	if c.layerMerge == nil {
+		c.layerMerge = make(map[string]*MergeOptions)
+	}
+	c.layerMerge[name] = mo
+}
+
+// merge data of the layers, which filter func returns true.
+// each layer is merged by the options of the last loading to it. see MergeWith()
+func (c *Config) mergeLayers(filter func(name string) bool) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	sep := string(c.opts.Delimiter)
+
+	for _, name := range c.layerOrder() {
+		if layer := c.layers[name]; len(layer) > 0 && filter(name) {
+			mo, ok := c.layerMerge[name]
+			if !ok {
+				mo = &c.opts.Merge
+			}
+
+			m := &merger{opts: mo, sep: sep}
+			if err := m.mergeMap(data, deepCopyMap(layer), ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return data, nil
+}
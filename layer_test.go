@@ -0,0 +1,126 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_layers(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.Equal(DefaultLayers, c.LayerNames())
+	is.True(c.HasLayer(LayerEnv))
+	is.False(c.HasLayer("not-exist"))
+
+	err := c.LoadStrings(JSON, `{"name": "app", "db": {"host": "localhost", "port": 3306}}`)
+	is.NoError(err)
+	is.Equal(LayerFiles, c.Origin("db.host"))
+
+	// runtime override
+	is.NoError(c.Set("db.port", 3307))
+	is.Equal(LayerRuntime, c.Origin("db.port"))
+	is.Equal(LayerFiles, c.Origin("db.host"))
+	is.Equal("", c.Origin("not-exist"))
+
+	// load to lower layer, runtime value is kept
+	err = c.LoadStrings(JSON, `{"db": {"host": "127.0.0.1", "port": 5432}}`)
+	is.NoError(err)
+	is.Equal("127.0.0.1", c.String("db.host"))
+	is.Equal(3307, c.Int("db.port"))
+
+	// reload files will not wipe runtime overrides
+	err = c.ReloadLayer(LayerFiles, func(c *Config) error {
+		return c.LoadStrings(JSON, `{"db": {"host": "db.local", "port": 5433}}`)
+	})
+	is.NoError(err)
+	is.Equal("db.local", c.String("db.host"))
+	is.Equal(3307, c.Int("db.port"))
+	is.False(c.Exists("name"))
+
+	// load to other layer
+	err = c.UseLayer(LayerEnv, func(c *Config) error {
+		return c.Set("db.host", "env.host")
+	})
+	is.NoError(err)
+	is.Equal("env.host", c.String("db.host"))
+	is.Equal(LayerEnv, c.Origin("db.host"))
+	is.Equal("env.host", c.LayerData(LayerEnv)["db"].(map[string]interface{})["host"])
+
+	// remove layer
+	is.NoError(c.RemoveLayer(LayerRuntime))
+	is.Equal(5433, c.Int("db.port"))
+	is.NoError(c.RemoveLayer(LayerEnv))
+	is.Equal("db.local", c.String("db.host"))
+
+	// replace layer
+	is.NoError(c.SetLayer(LayerDefaults, map[string]interface{}{"name": "def", "db": map[string]interface{}{"host": "def"}}))
+	is.Equal("def", c.String("name"))
+	is.Equal("db.local", c.String("db.host"))
+
+	// add layer
+	is.NoError(c.AddLayer("secrets", LayerFiles))
+	is.Error(c.AddLayer("secrets", ""))
+	is.Error(c.AddLayer("other", "not-exist"))
+	is.NoError(c.AddLayer("top", ""))
+	is.Equal([]string{LayerDefaults, LayerFiles, "secrets", LayerRemote, LayerEnv, LayerFlags, LayerRuntime, "top"}, c.LayerNames())
+
+	err = c.UseLayer("secrets", func(c *Config) error {
+		return c.LoadData(map[string]interface{}{"db": map[string]interface{}{"password": "pwd"}})
+	})
+	is.NoError(err)
+	is.Equal("secrets", c.Origin("db.password"))
+	is.Error(c.UseLayer("not-exist", func(c *Config) error { return nil }))
+	is.Error(c.ReloadLayer("not-exist", func(c *Config) error { return nil }))
+	is.Error(c.SetLayer("not-exist", nil))
+
+	// set array item in lower layer
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, `{"arr": ["a", "b"]}`))
+	is.NoError(c.Set("top", "val"))
+	err = c.UseLayer(LayerEnv, func(c *Config) error {
+		return c.Set("arr.1", "c")
+	})
+	is.NoError(err)
+	is.Equal([]string{"a", "c"}, c.Strings("arr"))
+
+	c.ClearData()
+	is.True(c.IsEmpty())
+	is.Empty(c.LayerData(LayerFiles))
+}
+
+func TestConfig_UseLayer_concurrent(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadData(map[string]interface{}{"port": 80}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = c.UseLayer(LayerRemote, func(c *Config) error {
+				return c.LoadData(map[string]interface{}{"remote": i})
+			})
+		}
+	}()
+
+	// the Set is not run in the layer scope of other callers
+	for i := 0; i < 50; i++ {
+		is.NoError(c.Set("port", 8000+i))
+	}
+	<-done
+
+	is.Equal(LayerRuntime, c.Origin("port"))
+	is.Equal(LayerRemote, c.Origin("remote"))
+	is.Contains(c.ChangedKeys(), "port")
+	is.NotContains(c.LayerData(LayerRemote), "port")
+
+	err := c.ReloadLayer(LayerRemote, func(c *Config) error {
+		return c.LoadData(map[string]interface{}{"remote": 1})
+	})
+	is.NoError(err)
+	is.Equal(8049, c.Int("port"))
+	is.Equal(1, c.Int("remote"))
+}
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/imdario/mergo"
+	"github.com/gookit/goutil/strutil"
 )
 
 // LoadFiles load one or multi files
@@ -48,6 +48,8 @@ func LoadRemote(format, url string) error { return dc.LoadRemote(format, url) }
 // Usage:
 // 	c.LoadRemote(config.JSON, "http://abc.com/api-config.json")
 func (c *Config) LoadRemote(format, url string) (err error) {
+	h := c.loadIn(LayerRemote)
+
 	// create http client
 	client := http.Client{Timeout: 300 * time.Second}
 	resp, err := client.Get(url)
@@ -66,10 +68,14 @@ func (c *Config) LoadRemote(format, url string) (err error) {
 	bts, err := ioutil.ReadAll(resp.Body)
 	if err == nil {
 		// parse file content
-		if err = c.parseSourceCode(format, bts); err != nil {
+		if err = h.parseSourceCode(format, bts); err != nil {
 			return
 		}
-		c.loadedFiles = append(c.loadedFiles, url)
+
+		root := c.rootConfig()
+		root.lock.Lock()
+		root.loadedFiles = append(root.loadedFiles, url)
+		root.lock.Unlock()
 	}
 	return
 }
@@ -79,6 +85,9 @@ func LoadOSEnv(keys []string, keyToLower bool) { dc.LoadOSEnv(keys, keyToLower)
 
 // LoadOSEnv load data from os ENV
 func (c *Config) LoadOSEnv(keys []string, keyToLower bool) {
+	h := c.loadIn(LayerEnv)
+
+	loaded := make([]string, 0, len(keys))
 	for _, key := range keys {
 		// NOTICE:
 		// if is windows os, os.Getenv() Key is not case sensitive
@@ -87,17 +96,23 @@ func (c *Config) LoadOSEnv(keys []string, keyToLower bool) {
 			key = strings.ToLower(key)
 		}
 
-		_ = c.Set(key, val)
+		if err := h.Set(key, val); err == nil {
+			loaded = append(loaded, key)
+		}
 	}
 
-	c.fireHook(OnLoadData)
+	h.notify(OnLoadData, h.layerFor(LayerEnv), loaded...)
 }
 
-// support bound types for CLI flags vars
+// support bound types for CLI flags vars. see FlagSpec.Type
 var validTypes = map[string]int{
-	"int":  1,
-	"uint": 1,
-	"bool": 1,
+	"int":      1,
+	"uint":     1,
+	"bool":     1,
+	"float":    1,
+	"duration": 1,
+	"strings":  1,
+	"map":      1,
 	// string is default
 	"string": 1,
 }
@@ -106,53 +121,21 @@ var validTypes = map[string]int{
 func LoadFlags(keys []string) error { return dc.LoadFlags(keys) }
 
 // LoadFlags parse command line arguments, based on provide keys.
+// the flags are bound to the flag.CommandLine, and the key is the flag name.
 //
 // Usage:
 // 	// debug flag is bool type
 // 	c.LoadFlags([]string{"env", "debug:bool"})
+//
+// More flag types and usage see LoadFlagSet()
 func (c *Config) LoadFlags(keys []string) (err error) {
-	hash := map[string]interface{}{}
-
-	// bind vars
+	specs := make([]FlagSpec, 0, len(keys))
 	for _, key := range keys {
 		key, typ := parseVarNameAndType(key)
-
-		switch typ {
-		case "int":
-			ptr := new(int)
-			flag.IntVar(ptr, key, c.Int(key), "")
-			hash[key] = ptr
-		case "uint":
-			ptr := new(uint)
-			flag.UintVar(ptr, key, c.Uint(key), "")
-			hash[key] = ptr
-		case "bool":
-			ptr := new(bool)
-			flag.BoolVar(ptr, key, c.Bool(key), "")
-			hash[key] = ptr
-		default: // as string
-			ptr := new(string)
-			flag.StringVar(ptr, key, c.String(key), "")
-			hash[key] = ptr
-		}
+		specs = append(specs, FlagSpec{Key: key, Name: key, Type: typ})
 	}
 
-	// parse and collect
-	flag.Parse()
-	flag.Visit(func(f *flag.Flag) {
-		name := f.Name
-		// name := strings.Replace(f.Name, "-", ".", -1)
-		// only get name in the keys.
-		if _, ok := hash[name]; !ok {
-			return
-		}
-
-		// ignore error
-		_ = c.Set(name, f.Value.String())
-	})
-
-	c.fireHook(OnLoadData)
-	return
+	return c.LoadFlagSet(flag.CommandLine, os.Args[1:], specs...)
 }
 
 // LoadData load one or multi data
@@ -167,15 +150,15 @@ func (c *Config) LoadData(dataSources ...interface{}) (err error) {
 		c.opts.Delimiter = defaultDelimiter
 	}
 
+	loaded := make([]map[string]interface{}, 0, len(dataSources))
 	for _, ds := range dataSources {
-		err = mergo.Merge(&c.data, ds, mergo.WithOverride)
-		if err != nil {
-			return
+		data := toStringMap(ds)
+		if data == nil {
+			return fmt.Errorf("config: cannot load data of the type %T", ds)
 		}
+		loaded = append(loaded, data)
 	}
-
-	c.fireHook(OnLoadData)
-	return
+	return c.mergeDocs(loaded)
 }
 
 // LoadSources load one or multi byte data
@@ -227,6 +210,70 @@ func (c *Config) LoadStrings(format string, str string, more ...string) (err err
 	return
 }
 
+// DocsOptions for load multi documents. see LoadDocuments()
+type DocsOptions struct {
+	// SelectKey and SelectValue for select documents by the discriminator key.
+	// eg: "profile: prod". documents without the key are always selected.
+	// the key is removed from the selected documents, it will not be merged to the config data.
+	SelectKey   string
+	SelectValue string
+}
+
+// SelectDocs option for load multi documents, select documents by the discriminator key.
+func SelectDocs(key, value string) func(*DocsOptions) {
+	return func(opts *DocsOptions) {
+		opts.SelectKey = key
+		opts.SelectValue = value
+	}
+}
+
+// LoadDocuments load multi documents content, documents are merged in order.
+func LoadDocuments(format string, src []byte, opts ...func(*DocsOptions)) error {
+	return dc.LoadDocuments(format, src, opts...)
+}
+
+// LoadDocuments load multi documents content, the documents are merged in order.
+//
+// Supported formats: yaml("---" separated, by the driver yaml or yamlv3), ndjson.
+// other formats will be loaded as one document.
+//
+// Usage:
+//
+//	// base document and per-environment overrides
+//	err := c.LoadDocuments(config.Yaml, []byte(`
+//	name: app
+//	debug: true
+//	---
+//	profile: prod
+//	debug: false
+//	`), config.SelectDocs("profile", "prod"))
+func (c *Config) LoadDocuments(format string, src []byte, opts ...func(*DocsOptions)) (err error) {
+	docsOpts := &DocsOptions{}
+	for _, fn := range opts {
+		fn(docsOpts)
+	}
+
+	docs, err := c.decodeDocs(format, src)
+	if err != nil {
+		return
+	}
+
+	sep := string(c.opts.Delimiter)
+	loaded := make([]map[string]interface{}, 0, len(docs))
+	for _, data := range docs {
+		if docsOpts.SelectKey != "" {
+			keys := strings.Split(docsOpts.SelectKey, sep)
+			val, ok := findByKeys(data, keys)
+			if ok && strutil.MustString(val) != docsOpts.SelectValue {
+				continue
+			}
+			deleteByKeys(data, keys)
+		}
+		loaded = append(loaded, data)
+	}
+	return c.mergeDocs(loaded)
+}
+
 // LoadFilesByFormat load one or multi files by give format
 func LoadFilesByFormat(format string, sourceFiles ...string) error {
 	return dc.LoadFilesByFormat(format, sourceFiles...)
@@ -279,47 +326,113 @@ func (c *Config) loadFile(file string, loadExist bool, format string) (err error
 			format = strings.Trim(filepath.Ext(file), ".")
 		}
 
-		// parse file content
-		if err = c.parseSourceCode(format, bts); err != nil {
-			return
+		// decode file content
+		docs, err := c.decodeFile(file, format, bts)
+		if err != nil {
+			return err
+		}
+
+		// load the included files first, the file data will override them. see IncludeKey
+		data := docs
+		if c.opts.IncludeKey != "" {
+			if data, err = c.loadIncludes(file, docs); err != nil {
+				return err
+			}
+		}
+
+		if err = c.mergeDocs(data); err != nil {
+			return err
 		}
 
-		c.loadedFiles = append(c.loadedFiles, file)
+		root := c.rootConfig()
+		root.lock.Lock()
+		root.loadedFiles = append(root.loadedFiles, file)
+
+		// record the file data for write back changes. see SaveChanges()
+		// NOTICE: multi documents file cannot be written back.
+		if len(docs) == 1 {
+			root.sources = append(root.sources, &fileSource{path: file, format: fixFormat(format), data: docs[0], prefix: c.prefix})
+		}
+		root.lock.Unlock()
 	}
 	return
 }
 
 // parse config source code to Config.
 func (c *Config) parseSourceCode(format string, blob []byte) (err error) {
+	_, err = c.parseSource(format, blob)
+	return
+}
+
+// parse config source code to Config, returns the decoded documents.
+func (c *Config) parseSource(format string, blob []byte) (docs []map[string]interface{}, err error) {
+	if docs, err = c.decodeDocs(format, blob); err != nil {
+		return
+	}
+
+	err = c.mergeDocs(docs)
+	return
+}
+
+// merge the documents to the layer and config data. multi documents are merged in order.
+// on the config is a view, the documents are mounted under the view prefix.
+func (c *Config) mergeDocs(docs []map[string]interface{}) (err error) {
+	root := c.rootConfig()
+	layer := c.layerFor(LayerFiles)
+	mo := c.mergeOptions()
+
+	root.lock.Lock()
+	for _, data := range mountDocs(c.prefix, docs, c.opts.Delimiter) {
+		if err = root.mergeLayer(layer, data, mo); err != nil {
+			break
+		}
+	}
+	root.lock.Unlock()
+
+	if err == nil {
+		c.notify(OnLoadData, layer, topKeys(docs...)...)
+	}
+	return
+}
+
+// decode the source code to documents. if the format not support
+// multi documents, will return one document.
+func (c *Config) decodeDocs(format string, blob []byte) ([]map[string]interface{}, error) {
+	return c.decodeFile("", format, blob)
+}
+
+// decode the file content to documents, the file path is given to the FileDriver.
+func (c *Config) decodeFile(file, format string, blob []byte) ([]map[string]interface{}, error) {
+	// the drivers are registered in the root config
+	if c.root != nil {
+		return c.root.decodeFile(file, format, blob)
+	}
+
 	format = fixFormat(format)
 	decode := c.decoders[format]
+	if fd := c.fileDrivers[format]; fd != nil && file != "" {
+		decode = func(blob []byte, v interface{}) error {
+			return fd.DecodeFile(file, blob, v)
+		}
+	}
+
 	if decode == nil {
-		return errors.New("not exists or not register decoder for the format: " + format)
+		return nil, errors.New("not exists or not register decoder for the format: " + format)
 	}
 
 	if c.opts.Delimiter == 0 {
 		c.opts.Delimiter = defaultDelimiter
 	}
 
-	data := make(map[string]interface{})
-
-	// decode content to data
-	if err = decode(blob, &data); err != nil {
-		return
+	if decodeDocs := c.docsDecoders[format]; decodeDocs != nil {
+		return decodeDocs(blob)
 	}
 
-	// init config data
-	if len(c.data) == 0 {
-		c.data = data
-	} else {
-		// again ... will merge data
-		// err = mergo.Map(&c.data, data, mergo.WithOverride)
-		err = mergo.Merge(&c.data, data, mergo.WithOverride, mergo.WithTypeCheck)
-	}
+	data := make(map[string]interface{})
 
-	if err != nil {
-		c.fireHook(OnLoadData)
+	// decode content to data
+	if err := decode(blob, &data); err != nil {
+		return nil, err
 	}
-	data = nil
-	return
+	return []map[string]interface{}{data}, nil
 }
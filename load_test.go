@@ -212,3 +212,69 @@ func TestLoadOSEnv(t *testing.T) {
 
 	ClearAll()
 }
+
+func TestLoadDocuments(t *testing.T) {
+	is := assert.New(t)
+
+	src := `
+{"name": "app", "db": {"host": "localhost", "port": 3306}}
+
+{"env": "dev", "db": {"host": "dev.local"}}
+{"env": "prod", "db": {"host": "prod.local"}, "tags": ["a", "b"]}
+`
+	c := New("test")
+	is.False(c.HasDecoder("jsonl"))
+	c.AddDriver(NDJSONDriver)
+	is.True(c.HasDecoder("jsonl"))
+
+	is.NoError(c.LoadStrings(NDJSON, src))
+	is.Equal("app", c.String("name"))
+	is.Equal("prod.local", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.Equal([]string{"a", "b"}, c.Strings("tags"))
+
+	c = New("test")
+	c.AddDriver(NDJSONDriver)
+	is.NoError(c.LoadDocuments("jsonl", []byte(src), SelectDocs("env", "dev")))
+	is.Equal("dev.local", c.String("db.host"))
+	is.False(c.Exists("tags"))
+	// the discriminator key is not merged
+	is.False(c.Exists("env"))
+
+	// select by key path
+	c = New("test")
+	c.AddDriver(NDJSONDriver)
+	is.NoError(c.LoadDocuments(NDJSON, []byte(`{"name": "app"}
+{"meta": {"env": "dev", "id": 1}, "name": "dev"}
+{"meta": {"env": "prod"}, "name": "prod"}`), SelectDocs("meta.env", "prod")))
+	is.Equal("prod", c.String("name"))
+	is.False(c.Exists("meta.env"))
+	is.False(c.Exists("meta.id"))
+
+	// not support multi documents, load as one document
+	c = New("test")
+	is.NoError(c.LoadDocuments(JSON, []byte(`{"name": "app"}`)))
+	is.Equal("app", c.String("name"))
+
+	is.Error(c.LoadDocuments("not-exist", []byte(src)))
+	is.Error(c.LoadDocuments(NDJSON, []byte(src)))
+
+	c.AddDriver(NDJSONDriver)
+	err := c.LoadDocuments(NDJSON, []byte("{\"a\": 1}\n{invalid}"))
+	is.Error(err)
+	is.Contains(err.Error(), "line 2")
+
+	// the decoder
+	data := map[string]interface{}{}
+	is.NoError(NDJSONDecoder([]byte(src), &data))
+	is.Equal("prod", data["env"])
+
+	st := struct{ Name string }{}
+	is.NoError(NDJSONDecoder([]byte(src), &st))
+	is.Equal("app", st.Name)
+	is.Error(NDJSONDecoder([]byte("\n"), &st))
+
+	bts, err := NDJSONDriver.GetEncoder()(map[string]interface{}{"a": 1})
+	is.NoError(err)
+	is.Equal(`{"a":1}`, string(bts))
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package config
+
+// lock the file, the advisory lock is not supported on the platform, do nothing.
+func lockFile(string) (unlock func(), err error) {
+	return func() {}, nil
+}
+
+// sync the dir, it's not supported on the platform, do nothing.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,43 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lock the file by an advisory lock on the dir of the file, so no lock file is left.
+// returns func for unlock.
+func lockFile(path string) (unlock func(), err error) {
+	fd, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = syscall.Flock(int(fd.Fd()), syscall.LOCK_EX); err != nil {
+		_ = fd.Close()
+		return nil, err
+	}
+
+	return func() {
+		_ = syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+		_ = fd.Close()
+	}, nil
+}
+
+// sync the dir, for persist the renamed or created files in it.
+func syncDir(dir string) error {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	err = fd.Sync()
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// ArrayMergeMode how to merge the array values
+type ArrayMergeMode uint8
+
+// There are array merge modes
+const (
+	// ArrayReplace replace the old array by new array. it's default mode
+	ArrayReplace ArrayMergeMode = iota
+	// ArrayAppend append new array items to the old array
+	ArrayAppend
+	// ArrayMergeByIndex merge the array items by index
+	ArrayMergeByIndex
+	// ArrayMergeByKey merge the map items by the MergeOptions.ArrayKeyField value
+	ArrayMergeByKey
+)
+
+// ConflictPolicy how to handle the value type conflicts on merge data.
+// eg: merge a string value to a map value.
+type ConflictPolicy uint8
+
+// There are type conflict policies
+const (
+	// ConflictOverride override the old value by new value. it's default policy
+	ConflictOverride ConflictPolicy = iota
+	// ConflictKeep keep the old value
+	ConflictKeep
+	// ConflictError return an error
+	ConflictError
+)
+
+// MergeOptions for merge config data on load new data
+type MergeOptions struct {
+	// Arrays the array merge mode. default is ArrayReplace
+	Arrays ArrayMergeMode
+	// ArrayKeyField the field name for match map items on use ArrayMergeByKey
+	ArrayKeyField string
+	// NullAsDelete delete the key on the value is null in the new data
+	NullAsDelete bool
+	// TypeConflict the policy on value types are conflict. default is ConflictOverride
+	TypeConflict ConflictPolicy
+}
+
+// WithMerge set the default merge options
+//
+// Usage:
+//
+//	c := config.NewWithOptions("app", config.WithMerge(config.MergeOptions{
+//		Arrays: config.ArrayAppend,
+//		NullAsDelete: true,
+//	}))
+func WithMerge(mo MergeOptions) func(*Options) {
+	return func(opts *Options) {
+		opts.Merge = mo
+	}
+}
+
+// MergeWith load data by call fn, and use the merge options for the loading.
+// the options are kept for the loaded layer, and replayed on rebuild the config data.
+//
+// The fn is called with a handle of the config, other callers of the config are not affected.
+//
+// Usage:
+//
+//	err := c.MergeWith(config.MergeOptions{Arrays: config.ArrayAppend}, func(c *config.Config) error {
+//		return c.LoadFiles("plugins.json")
+//	})
+func (c *Config) MergeWith(mo MergeOptions, fn func(c *Config) error) error {
+	ctx := c.ctx
+	ctx.merge = &mo
+	return fn(c.handle(c.prefix, ctx))
+}
+
+// get the merge options for current loading
+func (c *Config) mergeOptions() *MergeOptions {
+	if c.ctx.merge != nil {
+		return c.ctx.merge
+	}
+	return &c.rootConfig().opts.Merge
+}
+
+// merger for merge config data
+type merger struct {
+	opts *MergeOptions
+	sep  string
+	// keep null value as tombstone on NullAsDelete=true, use for merge to layer data
+	keepNull bool
+}
+
+// merge src map data to the dst map
+func (m *merger) mergeMap(dst, src map[string]interface{}, path string) error {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		sv := src[k]
+		subPath := k
+		if path != "" {
+			subPath = path + m.sep + k
+		}
+
+		dv, exists := dst[k]
+		if sv == nil {
+			if m.opts.NullAsDelete && !m.keepNull {
+				delete(dst, k)
+			} else if !exists || m.opts.NullAsDelete {
+				dst[k] = nil
+			}
+			continue
+		}
+
+		if !exists || dv == nil {
+			dst[k] = sv
+			continue
+		}
+
+		val, err := m.mergeValue(dv, sv, subPath)
+		if err != nil {
+			return err
+		}
+		dst[k] = val
+	}
+	return nil
+}
+
+// merge src value to the dst value, returns the merged value.
+func (m *merger) mergeValue(dv, sv interface{}, path string) (interface{}, error) {
+	dKind, sKind := valueKind(dv), valueKind(sv)
+	if dKind != sKind {
+		switch m.opts.TypeConflict {
+		case ConflictKeep:
+			return dv, nil
+		case ConflictError:
+			return nil, fmt.Errorf("config: cannot merge the key %q, value type conflict: %T <- %T", path, dv, sv)
+		}
+		return sv, nil
+	}
+
+	switch dKind {
+	case reflect.Map:
+		dm, sm := toStringMap(dv), toStringMap(sv)
+		if err := m.mergeMap(dm, sm, path); err != nil {
+			return nil, err
+		}
+
+		// keep the yaml map type
+		if _, ok := dv.(map[interface{}]interface{}); ok {
+			mp := make(map[interface{}]interface{}, len(dm))
+			for k, v := range dm {
+				mp[k] = v
+			}
+			return mp, nil
+		}
+		return dm, nil
+	case reflect.Slice:
+		if m.opts.Arrays == ArrayReplace {
+			return sv, nil
+		}
+		return m.mergeArray(toSlice(dv), toSlice(sv), path)
+	}
+	return sv, nil
+}
+
+// merge src array to the dst array by MergeOptions.Arrays
+func (m *merger) mergeArray(dst, src []interface{}, path string) (interface{}, error) {
+	switch m.opts.Arrays {
+	case ArrayAppend:
+		return append(dst, src...), nil
+	case ArrayMergeByIndex:
+		for i, sv := range src {
+			if i >= len(dst) {
+				dst = append(dst, sv)
+				continue
+			}
+
+			if sv == nil || dst[i] == nil {
+				dst[i] = sv
+				continue
+			}
+
+			val, err := m.mergeValue(dst[i], sv, path+m.sep+strutil.MustString(i))
+			if err != nil {
+				return nil, err
+			}
+			dst[i] = val
+		}
+		return dst, nil
+	case ArrayMergeByKey:
+		field := m.opts.ArrayKeyField
+		for _, sv := range src {
+			idx := findItemByField(dst, field, sv)
+			if idx < 0 {
+				dst = append(dst, sv)
+				continue
+			}
+
+			val, err := m.mergeValue(dst[idx], sv, path+m.sep+strutil.MustString(idx))
+			if err != nil {
+				return nil, err
+			}
+			dst[idx] = val
+		}
+		return dst, nil
+	}
+
+	// ArrayReplace
+	return src, nil
+}
+
+// find the map item index in the list, which field value is equals to the field value of the item.
+func findItemByField(list []interface{}, field string, item interface{}) int {
+	if field == "" || valueKind(item) != reflect.Map {
+		return -1
+	}
+
+	want, ok := toStringMap(item)[field]
+	if !ok {
+		return -1
+	}
+
+	for i, v := range list {
+		if valueKind(v) != reflect.Map {
+			continue
+		}
+
+		if got, ok := toStringMap(v)[field]; ok && reflect.DeepEqual(got, want) {
+			return i
+		}
+	}
+	return -1
+}
+
+// get value kind for check conflict. returns Map, Slice or Invalid(as scalar)
+func valueKind(val interface{}) reflect.Kind {
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Map:
+		return reflect.Map
+	case reflect.Slice, reflect.Array:
+		return reflect.Slice
+	}
+	return reflect.Invalid
+}
+
+// convert a map value to map[string]interface{}
+func toStringMap(val interface{}) map[string]interface{} {
+	switch typVal := val.(type) {
+	case map[string]interface{}:
+		return typVal
+	case map[interface{}]interface{}:
+		mp := make(map[string]interface{}, len(typVal))
+		for k, v := range typVal {
+			mp[strutil.MustString(k)] = v
+		}
+		return mp
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Map {
+		return nil
+	}
+
+	mp := make(map[string]interface{}, rv.Len())
+	for _, key := range rv.MapKeys() {
+		mp[strutil.MustString(key.Interface())] = rv.MapIndex(key).Interface()
+	}
+	return mp
+}
+
+// convert a slice value to []interface{}
+func toSlice(val interface{}) []interface{} {
+	if arr, ok := val.([]interface{}); ok {
+		return arr
+	}
+
+	rv := reflect.ValueOf(val)
+	arr := make([]interface{}, rv.Len())
+	for i := range arr {
+		arr[i] = rv.Index(i).Interface()
+	}
+	return arr
+}
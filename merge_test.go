@@ -0,0 +1,163 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_MergeWith(t *testing.T) {
+	is := assert.New(t)
+
+	base := `{
+	"name": "app",
+	"tags": ["a", "b"],
+	"servers": [{"name": "s1", "port": 80}, {"name": "s2", "port": 81}],
+	"db": {"host": "localhost", "port": 3306}
+}`
+
+	// default: replace array
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, base, `{"tags": ["c"], "db": {"port": 3307}}`))
+	is.Equal([]string{"c"}, c.Strings("tags"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(3307, c.Int("db.port"))
+
+	// append array
+	c = NewWithOptions("test", WithMerge(MergeOptions{Arrays: ArrayAppend}))
+	is.NoError(c.LoadStrings(JSON, base, `{"tags": ["c"]}`))
+	is.Equal([]string{"a", "b", "c"}, c.Strings("tags"))
+
+	// merge by index
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, base))
+	err := c.MergeWith(MergeOptions{Arrays: ArrayMergeByIndex}, func(c *Config) error {
+		return c.LoadStrings(JSON, `{"tags": ["x"], "servers": [{"port": 90}]}`)
+	})
+	is.NoError(err)
+	is.Equal([]string{"x", "b"}, c.Strings("tags"))
+	is.Equal("s1", c.String("servers.0.name"))
+	is.Equal(90, c.Int("servers.0.port"))
+
+	// merge by key field
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, base))
+	err = c.MergeWith(MergeOptions{Arrays: ArrayMergeByKey, ArrayKeyField: "name"}, func(c *Config) error {
+		return c.LoadStrings(JSON, `{"servers": [{"name": "s2", "port": 91}, {"name": "s3", "port": 92}]}`)
+	})
+	is.NoError(err)
+	is.Equal(80, c.Int("servers.0.port"))
+	is.Equal(91, c.Int("servers.1.port"))
+	is.Equal("s3", c.String("servers.2.name"))
+
+	// null as delete
+	c = NewWithOptions("test", WithMerge(MergeOptions{NullAsDelete: true}))
+	is.NoError(c.LoadStrings(JSON, base, `{"name": null, "db": {"host": null}}`))
+	is.False(c.Exists("name"))
+	is.False(c.Exists("db.host"))
+	is.True(c.Exists("db.port"))
+
+	// null is ignored by default
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, base, `{"name": null}`))
+	is.Equal("app", c.String("name"))
+
+	// type conflict
+	c = NewWithOptions("test", WithMerge(MergeOptions{TypeConflict: ConflictKeep}))
+	is.NoError(c.LoadStrings(JSON, base, `{"db": "invalid"}`))
+	is.Equal("localhost", c.String("db.host"))
+
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, base, `{"db": "override"}`))
+	is.Equal("override", c.String("db"))
+
+	c = NewWithOptions("test", WithMerge(MergeOptions{TypeConflict: ConflictError}))
+	is.NoError(c.LoadStrings(JSON, base))
+	err = c.LoadStrings(JSON, `{"db": ["invalid"]}`)
+	is.Error(err)
+	is.Contains(err.Error(), `"db"`)
+	is.Equal("localhost", c.String("db.host"))
+
+	// false value will override
+	c = New("test")
+	is.NoError(c.LoadStrings(JSON, `{"debug": true}`, `{"debug": false}`))
+	is.False(c.Bool("debug"))
+}
+
+func TestConfig_MergeWith_rebuild(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.SetDefault("tags", []string{"a"}))
+	err := c.MergeWith(MergeOptions{Arrays: ArrayAppend}, func(c *Config) error {
+		return c.LoadStrings(JSON, `{"tags": ["b"]}`)
+	})
+	is.NoError(err)
+	is.Equal([]string{"a", "b"}, c.Strings("tags"))
+
+	// the layer data is merged by the recorded options on rebuild
+	is.NoError(c.SetLayer(LayerRemote, map[string]interface{}{"name": "app"}))
+	is.Equal([]string{"a", "b"}, c.Strings("tags"))
+	is.NoError(c.RemoveLayer(LayerRemote))
+	is.Equal([]string{"a", "b"}, c.Strings("tags"))
+
+	// reset by reload the layer
+	err = c.ReloadLayer(LayerFiles, func(c *Config) error {
+		return c.LoadStrings(JSON, `{"tags": ["c"]}`)
+	})
+	is.NoError(err)
+	is.Equal([]string{"c"}, c.Strings("tags"))
+}
+
+func TestConfig_MergeWith_concurrent(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = c.UseLayer(LayerRemote, func(c *Config) error {
+				return c.MergeWith(MergeOptions{Arrays: ArrayAppend}, func(c *Config) error {
+					return c.LoadData(map[string]interface{}{"plugins": []interface{}{i}})
+				})
+			})
+		}
+	}()
+
+	// the loading is not run with the merge options of other callers
+	for i := 0; i < 50; i++ {
+		is.NoError(c.LoadData(map[string]interface{}{"tags": []interface{}{i}}))
+	}
+	<-done
+
+	is.Equal([]interface{}{49}, c.LayerData(LayerFiles)["tags"])
+	is.Len(c.LayerData(LayerRemote)["plugins"], 50)
+	is.NotContains(c.LayerData(LayerFiles), "plugins")
+}
+
+func TestConfig_Set_mergeOptions(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", WithMerge(MergeOptions{Arrays: ArrayAppend}))
+	is.NoError(c.LoadStrings(JSON, `{"tags": ["a"]}`))
+	is.NoError(c.Set("tags", []string{"x"}))
+	is.Equal([]string{"x"}, c.Strings("tags"))
+
+	// the set value is kept on rebuild data
+	is.NoError(c.LoadStrings(JSON, `{"name": "b"}`))
+	is.Equal([]string{"x"}, c.Strings("tags"))
+
+	c = NewWithOptions("test", WithMerge(MergeOptions{TypeConflict: ConflictKeep}))
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost"}}`))
+	is.NoError(c.Set("db", "dsn://x"))
+	is.NoError(c.LoadStrings(JSON, `{"db": {"port": 3306}}`))
+	is.Equal("dsn://x", c.Get("db"))
+
+	c = NewWithOptions("test", WithMerge(MergeOptions{TypeConflict: ConflictError}))
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "localhost"}}`))
+	is.NoError(c.Set("db", "dsn://x"))
+	is.NoError(c.LoadStrings(JSON, `{"name": "app"}`))
+	is.Equal("dsn://x", c.Get("db"))
+	is.Equal("app", c.String("name"))
+}
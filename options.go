@@ -2,7 +2,6 @@ package config
 
 import (
 	"github.com/mitchellh/mapstructure"
-	"strings"
 )
 
 // there are some event names for config data changed.
@@ -20,6 +19,10 @@ type HookFunc func(event string, c *Config)
 type Options struct {
 	// ParseEnv parse env value. like: "${EnvName}" "${EnvName|default}"
 	ParseEnv bool
+	// ParseRefs parse references to other config keys. like: "${server.host}" "${server.port|80}"
+	//
+	// NOTICE: on enable ParseEnv too, will use ENV value on the referenced key is not exists.
+	ParseRefs bool
 	// ParseTime parses a duration string to time.Duration
 	// eg: 10s, 2m
 	ParseTime bool
@@ -27,6 +30,8 @@ type Options struct {
 	Readonly bool
 	// EnableCache enable config data cache
 	EnableCache bool
+	// TrackAccess record the read access of keys, see Config.AccessReport()
+	TrackAccess bool
 	// ParseKey parse key path, allow find value by key path. eg: 'key.sub' will find `map[key]sub`
 	ParseKey bool
 	// TagName tag name for binding data to struct
@@ -38,10 +43,27 @@ type Options struct {
 	DumpFormat string
 	// ReadFormat default input format
 	ReadFormat string
+	// Merge options for merge data on load new data.
+	Merge MergeOptions
+	// OverridesFile the file for save the changed keys which not owned by any loaded file. see SaveChanges()
+	OverridesFile string
+	// ProfileEnv the ENV var name for get the profile on it is not given. default is "APP_ENV". see LoadProfile()
+	ProfileEnv string
+	// IncludeKey the reserved key for include other files in the config file, disabled on empty.
+	// eg: "_include". see EnableInclude()
+	IncludeKey string
+	// Resolvers the enabled built-in value resolvers. eg: "file", "env". see EnableResolvers()
+	Resolvers []string
+	// KeyProvider provide key for decrypt the encrypted values. see IsEncrypted()
+	KeyProvider KeyProvider
 	// DecoderConfig setting for binding data to struct. such as: TagName
 	DecoderConfig *mapstructure.DecoderConfig
 	// HookFunc on data changed.
 	HookFunc HookFunc
+	// AsyncEvents call the event handlers in a background goroutine, the events are still dispatched in order.
+	AsyncEvents bool
+	// AutoSave options for auto save data to file on data changed. see WithAutoSave()
+	AutoSave *AutoSaveOptions
 }
 
 func newDefaultOption() *Options {
@@ -71,6 +93,11 @@ func (o *Options) shouldAddHookFunc() bool {
 	return o.ParseTime || o.ParseEnv
 }
 
+// should parse ENV value on read string value. on ParseRefs=true, it's has been parsed.
+func (o *Options) shouldParseEnv() bool {
+	return o.ParseEnv && !o.ParseRefs
+}
+
 /*************************************************************
  * config setting
  *************************************************************/
@@ -78,6 +105,9 @@ func (o *Options) shouldAddHookFunc() bool {
 // ParseEnv set parse env value
 func ParseEnv(opts *Options) { opts.ParseEnv = true }
 
+// ParseRefs set parse references to other config keys
+func ParseRefs(opts *Options) { opts.ParseRefs = true }
+
 // ParseTime set parse time string.
 func ParseTime(opts *Options) { opts.ParseTime = true }
 
@@ -91,17 +121,20 @@ func Delimiter(sep byte) func(*Options) {
 	}
 }
 
-// WithSetSaveFile set hook func
+// WithSetSaveFile save data to the file on each data changed.
+// the save error will be recorded, see Config.Error()
+//
+// Deprecated: please use WithAutoSave()
 func WithSetSaveFile(fileName string, format string) func(options *Options) {
+	return WithAutoSave(fileName, func(o *AutoSaveOptions) {
+		o.Format = format
+	})
+}
+
+// WithProfileEnv set the ENV var name for get the profile. see Config.LoadProfile()
+func WithProfileEnv(name string) func(*Options) {
 	return func(opts *Options) {
-		opts.HookFunc = func(event string, c *Config) {
-			if strings.HasPrefix(event, "set.") {
-				err := c.DumpToFile(fileName, format)
-				if err != nil {
-					panic(err)
-				}
-			}
-		}
+		opts.ProfileEnv = name
 	}
 }
 
@@ -112,9 +145,15 @@ func WithHookFunc(fn HookFunc) func(*Options) {
 	}
 }
 
+// AsyncEvents set call the event handlers in a background goroutine. see Config.Subscribe()
+func AsyncEvents(opts *Options) { opts.AsyncEvents = true }
+
 // EnableCache set readonly
 func EnableCache(opts *Options) { opts.EnableCache = true }
 
+// TrackAccess enable record read access of the config keys
+func TrackAccess(opts *Options) { opts.TrackAccess = true }
+
 // WithOptions with options
 func WithOptions(opts ...func(*Options)) { dc.WithOptions(opts...) }
 
@@ -152,5 +191,9 @@ func (c *Config) With(fn func(c *Config)) *Config {
 //	config.LoadFiles(a, b, c)
 //	config.Readonly()
 func (c *Config) Readonly() {
+	if c.root != nil {
+		c.root.Readonly()
+		return
+	}
 	c.opts.Readonly = true
 }
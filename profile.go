@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultProfileEnv the default ENV var name for get the profile. see LoadProfile()
+const DefaultProfileEnv = "APP_ENV"
+
+// the local profile name, the file is for local overrides. eg: "app.local.yaml"
+const localProfile = "local"
+
+// LoadProfile load the base file and the profile files
+func LoadProfile(baseFile, profile string) error {
+	return dc.LoadProfile(baseFile, profile)
+}
+
+// LoadProfile load the base file, then the profile file "base.<profile>.<ext>" and
+// the local file "base.local.<ext>" if they exist. the later file will override the former.
+//
+// If the profile is empty, will get it from the ENV var by Options.ProfileEnv,
+// default is "APP_ENV". the base file is required, the profile files are optional.
+//
+// Usage:
+//
+//	// APP_ENV=prod: load app.yaml, app.prod.yaml, app.local.yaml
+//	err := c.LoadProfile("config/app.yaml", "")
+//	fmt.Println(c.Profile(), c.ProfileFiles())
+func (c *Config) LoadProfile(baseFile, profile string) error {
+	if profile == "" {
+		profile = os.Getenv(c.profileEnv())
+	}
+	profile = strings.TrimSpace(profile)
+
+	if err := c.LoadFiles(baseFile); err != nil {
+		return err
+	}
+
+	names := []string{localProfile}
+	if profile != "" && profile != localProfile {
+		names = []string{profile, localProfile}
+	}
+
+	loaded := []string{baseFile}
+	for _, name := range names {
+		file := profileFile(baseFile, name)
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+
+		if err := c.LoadFiles(file); err != nil {
+			return err
+		}
+		loaded = append(loaded, file)
+	}
+
+	c.profile = profile
+	c.profileFiles = loaded
+	return nil
+}
+
+// Profile get the active profile name
+func Profile() string { return dc.Profile() }
+
+// Profile get the active profile name. see LoadProfile()
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// ProfileFiles get the loaded profile files, in load order.
+func ProfileFiles() []string { return dc.ProfileFiles() }
+
+// ProfileFiles get the files loaded by LoadProfile(), in load order.
+func (c *Config) ProfileFiles() []string {
+	return c.profileFiles
+}
+
+func (c *Config) profileEnv() string {
+	if c.opts.ProfileEnv != "" {
+		return c.opts.ProfileEnv
+	}
+	return DefaultProfileEnv
+}
+
+// get the profile file path. eg: "config/app.yaml" + "dev" => "config/app.dev.yaml"
+func profileFile(baseFile, profile string) string {
+	ext := filepath.Ext(baseFile)
+	return strings.TrimSuffix(baseFile, ext) + "." + profile + ext
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_LoadProfile(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.json")
+	is.NoError(ioutil.WriteFile(base, []byte(`{"name": "app", "debug": false, "db": {"host": "localhost", "port": 3306}}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(dir, "app.prod.json"), []byte(`{"db": {"host": "db.prod"}}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(dir, "app.local.json"), []byte(`{"debug": true}`), 0600))
+
+	c := New("test")
+	is.NoError(c.LoadProfile(base, "prod"))
+	is.Equal("prod", c.Profile())
+	is.Equal([]string{
+		base,
+		filepath.Join(dir, "app.prod.json"),
+		filepath.Join(dir, "app.local.json"),
+	}, c.ProfileFiles())
+
+	is.Equal("app", c.String("name"))
+	is.Equal("db.prod", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.True(c.Bool("debug"))
+
+	// the profile file not exists
+	c = New("test")
+	is.NoError(c.LoadProfile(base, "dev"))
+	is.Equal("dev", c.Profile())
+	is.Equal([]string{base, filepath.Join(dir, "app.local.json")}, c.ProfileFiles())
+	is.Equal("localhost", c.String("db.host"))
+
+	// the "local" profile is loaded once
+	c = New("test")
+	is.NoError(c.LoadProfile(base, "local"))
+	is.Equal([]string{base, filepath.Join(dir, "app.local.json")}, c.ProfileFiles())
+
+	// the base file is required
+	c = New("test")
+	is.Error(c.LoadProfile(filepath.Join(dir, "other.json"), "prod"))
+	is.Empty(c.ProfileFiles())
+
+	// invalid profile file
+	is.NoError(ioutil.WriteFile(filepath.Join(dir, "app.bad.json"), []byte(`{invalid`), 0600))
+	is.Error(c.LoadProfile(base, "bad"))
+}
+
+func TestConfig_LoadProfile_env(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.json")
+	is.NoError(ioutil.WriteFile(base, []byte(`{"name": "app"}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(dir, "app.test.json"), []byte(`{"name": "app-test"}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(dir, "app.stage.json"), []byte(`{"name": "app-stage"}`), 0600))
+
+	testutil.MockEnvValues(map[string]string{
+		DefaultProfileEnv: "test",
+		"MY_APP_ENV":      "stage",
+	}, func() {
+		c := New("test")
+		is.NoError(c.LoadProfile(base, ""))
+		is.Equal("test", c.Profile())
+		is.Equal("app-test", c.String("name"))
+
+		// the explicit profile first
+		c = New("test")
+		is.NoError(c.LoadProfile(base, "none"))
+		is.Equal("none", c.Profile())
+		is.Equal("app", c.String("name"))
+
+		// custom ENV var name
+		c = NewWithOptions("test", WithProfileEnv("MY_APP_ENV"))
+		is.NoError(c.LoadProfile(base, ""))
+		is.Equal("stage", c.Profile())
+		is.Equal("app-stage", c.String("name"))
+
+		// package level
+		is.NoError(LoadProfile(base, ""))
+		is.Equal("test", Profile())
+		is.Equal([]string{base, filepath.Join(dir, "app.test.json")}, ProfileFiles())
+		is.Equal("app-test", String("name"))
+		ClearAll()
+		is.Equal("", Profile())
+	})
+
+	// no profile
+	c := New("test")
+	is.NoError(c.LoadProfile(base, ""))
+	is.Equal("", c.Profile())
+	is.Equal([]string{base}, c.ProfileFiles())
+}
+
+func TestProfileFile(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal("config/app.dev.yaml", profileFile("config/app.yaml", "dev"))
+	is.Equal("app.local", profileFile("app", "local"))
+	is.Equal("/etc/.app.prod.toml", profileFile("/etc/.app.toml", "prod"))
+}
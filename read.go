@@ -2,10 +2,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
-	"github.com/gookit/goutil/envutil"
 	"github.com/gookit/goutil/mathutil"
 	"github.com/gookit/goutil/strutil"
 )
@@ -20,11 +20,21 @@ func Exists(key string, findByPath ...bool) bool { return dc.Exists(key, findByP
 
 // Exists key exists check
 func (c *Config) Exists(key string, findByPath ...bool) (ok bool) {
+	if c.root != nil {
+		return c.root.Exists(c.viewKey(key), findByPath...)
+	}
+
 	sep := c.opts.Delimiter
 	if key = formatKey(key, string(sep)); key == "" {
 		return
 	}
 
+	if c.hasEnvBinding() {
+		if _, ok = c.envValue(key); ok {
+			return
+		}
+	}
+
 	if _, ok = c.data[key]; ok {
 		return
 	}
@@ -98,6 +108,13 @@ func Data() map[string]interface{} { return dc.Data() }
 
 // Data get all config data
 func (c *Config) Data() map[string]interface{} {
+	if c.root != nil {
+		return c.viewData()
+	}
+
+	if c.opts.ParseRefs {
+		return c.resolveData(c.data, false)
+	}
 	return c.data
 }
 
@@ -119,10 +136,8 @@ func GetValue(key string, findByPath ...bool) (interface{}, bool) {
 
 // GetValue get value by given key string.
 func (c *Config) GetValue(key string, findByPath ...bool) (value interface{}, ok bool) {
-	sep := c.opts.Delimiter
-	if key = formatKey(key, string(sep)); key == "" {
-		c.addError(errInvalidKey)
-		return
+	if c.root != nil {
+		return c.root.GetValue(c.viewKey(key), findByPath...)
 	}
 
 	// if not is readonly
@@ -131,6 +146,58 @@ func (c *Config) GetValue(key string, findByPath ...bool) (value interface{}, ok
 		defer c.lock.RUnlock()
 	}
 
+	if !c.opts.ParseRefs && c.opts.KeyProvider == nil {
+		return c.getValue(key, findByPath...)
+	}
+
+	prevErr := c.err
+	var err error
+	if value, ok = c.getValue(key, findByPath...); ok {
+		key = formatKey(key, string(c.opts.Delimiter))
+		if value, err = c.resolveValue(key, value, true); err != nil {
+			c.addError(err)
+		}
+	} else if !c.opts.ParseRefs {
+		return
+	} else if value, ok, err = c.getValueByRefs(key); ok {
+		// found by the referenced value. eg: "srv: ${server}", get "srv.host"
+		c.err = prevErr
+		if err != nil {
+			c.addError(err)
+		}
+	}
+	return
+}
+
+// get raw value by given key string.
+func (c *Config) getValue(key string, findByPath ...bool) (value interface{}, ok bool) {
+	sep := c.opts.Delimiter
+	if key = formatKey(key, string(sep)); key == "" {
+		c.addError(errInvalidKey)
+		return
+	}
+
+	if c.opts.TrackAccess {
+		defer func() {
+			if ok {
+				c.recordAccess(key)
+			}
+		}()
+	}
+
+	// override by the ENV value. see AutomaticEnv(), BindEnv()
+	if c.hasEnvBinding() {
+		defer func() {
+			if env, found := c.layerEnvValue(key); found {
+				value, ok = convertLike(env, value), true
+			} else if ok {
+				value = c.overlayEnv(key, value)
+			} else if mp, _ := c.overlayEnv(key, map[string]interface{}{}).(map[string]interface{}); len(mp) > 0 {
+				value, ok = mp, true
+			}
+		}()
+	}
+
 	// is top key
 	if value, ok = c.data[key]; ok {
 		return
@@ -239,10 +306,20 @@ func (c *Config) String(key string, defVal ...string) string {
 }
 
 func (c *Config) getString(key string) (value string, ok bool) {
+	// the view use the cache of the root
+	if c.root != nil {
+		return c.root.getString(c.viewKey(key))
+	}
+
 	// find from cache
-	if c.opts.EnableCache && len(c.strCache) > 0 {
+	var gen uint64
+	if c.opts.EnableCache {
+		c.cacheLock.Lock()
 		value, ok = c.strCache[key]
+		gen = c.cacheGen
+		c.cacheLock.Unlock()
 		if ok {
+			c.onCacheHit(key)
 			return
 		}
 	}
@@ -256,8 +333,8 @@ func (c *Config) getString(key string) (value string, ok bool) {
 	// from json `int` always is float64
 	case string:
 		value = typVal
-		if c.opts.ParseEnv {
-			value = envutil.ParseEnvValue(value)
+		if c.opts.shouldParseEnv() {
+			value = c.parseEnvValue(value)
 		}
 	default:
 		// value = fmt.Sprintf("%v", val)
@@ -266,10 +343,14 @@ func (c *Config) getString(key string) (value string, ok bool) {
 
 	// add cache
 	if ok && c.opts.EnableCache {
-		if c.strCache == nil {
-			c.strCache = make(map[string]string)
+		c.cacheLock.Lock()
+		if gen == c.cacheGen {
+			if c.strCache == nil {
+				c.strCache = make(map[string]string)
+			}
+			c.strCache[key] = value
 		}
-		c.strCache[key] = value
+		c.cacheLock.Unlock()
 	}
 	return
 }
@@ -326,7 +407,7 @@ func (c *Config) tryInt64(key string) (value int64, ok bool) {
 
 	value, err := strconv.ParseInt(strVal, 10, 0)
 	if err != nil {
-		c.addError(err)
+		c.addValueError(key, strVal, err)
 	}
 	return
 }
@@ -346,7 +427,7 @@ func (c *Config) Float(key string, defVal ...float64) (value float64) {
 
 	value, err := strconv.ParseFloat(str, 64)
 	if err != nil {
-		c.addError(err)
+		c.addValueError(key, str, err)
 	}
 	return
 }
@@ -380,7 +461,7 @@ func (c *Config) Bool(key string, defVal ...bool) (value bool) {
 	case "1", "true", "yes":
 		value = true
 	default:
-		c.addErrorf("the value '%s' cannot be convert to bool", lowerCase)
+		c.addValueError(key, lowerCase, fmt.Errorf("the value '%s' cannot be convert to bool", lowerCase))
 	}
 	return
 }
@@ -403,11 +484,11 @@ func (c *Config) Ints(key string) (arr []int) {
 	case []int:
 		arr = typeData
 	case []interface{}:
-		for _, v := range typeData {
+		for i, v := range typeData {
 			iv, err := mathutil.ToInt(v)
 			// iv, err := strconv.Atoi(fmt.Sprintf("%v", v))
 			if err != nil {
-				c.addError(err)
+				c.addValueError(key+string(c.opts.Delimiter)+strconv.Itoa(i), strutil.MustString(v), err)
 				arr = arr[0:0] // reset
 				return
 			}
@@ -439,7 +520,7 @@ func (c *Config) IntMap(key string) (mp map[string]int) {
 			// iv, err := strconv.Atoi(fmt.Sprintf("%v", v))
 			iv, err := mathutil.ToInt(v)
 			if err != nil {
-				c.addError(err)
+				c.addValueError(key+string(c.opts.Delimiter)+k, strutil.MustString(v), err)
 				mp = map[string]int{} // reset
 				return
 			}
@@ -448,16 +529,16 @@ func (c *Config) IntMap(key string) (mp map[string]int) {
 	case map[interface{}]interface{}: // if decode from yaml
 		mp = make(map[string]int)
 		for k, v := range typeData {
+			// sk := fmt.Sprintf("%v", k)
+			sk, _ := strutil.AnyToString(k, false)
+
 			// iv, err := strconv.Atoi(fmt.Sprintf( "%v", v))
 			iv, err := mathutil.ToInt(v)
 			if err != nil {
-				c.addError(err)
+				c.addValueError(key+string(c.opts.Delimiter)+sk, strutil.MustString(v), err)
 				mp = map[string]int{} // reset
 				return
 			}
-
-			// sk := fmt.Sprintf("%v", k)
-			sk, _ := strutil.AnyToString(k, false)
 			mp[sk] = iv
 		}
 	default:
@@ -471,11 +552,21 @@ func Strings(key string) []string { return dc.Strings(key) }
 
 // Strings get config data as a string slice/array
 func (c *Config) Strings(key string) (arr []string) {
+	// the view use the cache of the root
+	if c.root != nil {
+		return c.root.Strings(c.viewKey(key))
+	}
+
 	var ok bool
 	// find from cache
-	if c.opts.EnableCache && len(c.sArrCache) > 0 {
+	var gen uint64
+	if c.opts.EnableCache {
+		c.cacheLock.Lock()
 		arr, ok = c.sArrCache[key]
+		gen = c.cacheGen
+		c.cacheLock.Unlock()
 		if ok {
+			c.onCacheHit(key)
 			return
 		}
 	}
@@ -500,10 +591,14 @@ func (c *Config) Strings(key string) (arr []string) {
 
 	// add cache
 	if c.opts.EnableCache {
-		if c.sArrCache == nil {
-			c.sArrCache = make(map[string]strArr)
+		c.cacheLock.Lock()
+		if gen == c.cacheGen {
+			if c.sArrCache == nil {
+				c.sArrCache = make(map[string]strArr)
+			}
+			c.sArrCache[key] = arr
 		}
-		c.sArrCache[key] = arr
+		c.cacheLock.Unlock()
 	}
 	return
 }
@@ -513,12 +608,22 @@ func StringMap(key string) map[string]string { return dc.StringMap(key) }
 
 // StringMap get config data as a map[string]string
 func (c *Config) StringMap(key string) (mp map[string]string) {
+	// the view use the cache of the root
+	if c.root != nil {
+		return c.root.StringMap(c.viewKey(key))
+	}
+
 	var ok bool
 
 	// find from cache
-	if c.opts.EnableCache && len(c.sMapCache) > 0 {
+	var gen uint64
+	if c.opts.EnableCache {
+		c.cacheLock.Lock()
 		mp, ok = c.sMapCache[key]
+		gen = c.cacheGen
+		c.cacheLock.Unlock()
 		if ok {
+			c.onCacheHit(key)
 			return
 		}
 	}
@@ -537,8 +642,8 @@ func (c *Config) StringMap(key string) (mp map[string]string) {
 		for k, v := range typeData {
 			switch tv := v.(type) {
 			case string:
-				if c.opts.ParseEnv {
-					mp[k] = envutil.ParseEnvValue(tv)
+				if c.opts.shouldParseEnv() {
+					mp[k] = c.parseEnvValue(tv)
 				} else {
 					mp[k] = tv
 				}
@@ -554,8 +659,8 @@ func (c *Config) StringMap(key string) (mp map[string]string) {
 
 			switch typVal := v.(type) {
 			case string:
-				if c.opts.ParseEnv {
-					mp[sk] = envutil.ParseEnvValue(typVal)
+				if c.opts.shouldParseEnv() {
+					mp[sk] = c.parseEnvValue(typVal)
 				} else {
 					mp[sk] = typVal
 				}
@@ -571,10 +676,23 @@ func (c *Config) StringMap(key string) (mp map[string]string) {
 
 	// add cache
 	if c.opts.EnableCache {
-		if c.sMapCache == nil {
-			c.sMapCache = make(map[string]strMap)
+		c.cacheLock.Lock()
+		if gen == c.cacheGen {
+			if c.sMapCache == nil {
+				c.sMapCache = make(map[string]strMap)
+			}
+			c.sMapCache[key] = mp
 		}
-		c.sMapCache[key] = mp
+		c.cacheLock.Unlock()
 	}
 	return
 }
+
+// record access on read value from cache
+func (c *Config) onCacheHit(key string) {
+	if c.root != nil {
+		c.root.onCacheHit(c.viewKey(key))
+	} else if c.opts.TrackAccess {
+		c.recordAccess(formatKey(key, string(c.opts.Delimiter)))
+	}
+}
@@ -0,0 +1,408 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gookit/goutil/arrutil"
+	"github.com/gookit/goutil/strutil"
+)
+
+// RedactedValue the mask for the sensitive values on dump data and in error messages.
+var RedactedValue = "******"
+
+// AddSensitive mark keys as sensitive by the key path patterns
+func AddSensitive(patterns ...string) { dc.AddSensitive(patterns...) }
+
+// AddSensitive mark keys as sensitive by the key path patterns, "*" matches any chars.
+// the patterns are matched case-insensitively.
+//
+// The values of sensitive keys will be masked on ToJSON, DumpTo, WriteTo and in error messages.
+// use the dump option RevealSecrets for output the real values.
+//
+// Keys are sensitive also by:
+//   - the struct tag `secret:"true"` on binding data by Structure()
+//   - the value is resolved by a resolver with the option SensitiveResolver
+//   - the value is encrypted. see IsEncrypted()
+//
+// Usage:
+//
+//	c.AddSensitive("*.password", "*token*")
+func (c *Config) AddSensitive(patterns ...string) {
+	sep := string(c.opts.Delimiter)
+	if c.root != nil {
+		full := make([]string, 0, len(patterns))
+		for _, pattern := range patterns {
+			if pattern = c.viewKey(pattern); pattern != "" {
+				full = append(full, pattern)
+			}
+		}
+		c.root.AddSensitive(full...)
+		return
+	}
+
+	c.sensLock.Lock()
+	defer c.sensLock.Unlock()
+
+	for _, pattern := range patterns {
+		if pattern = strings.ToLower(formatKey(pattern, sep)); pattern == "" {
+			continue
+		}
+
+		if !arrutil.StringsHas(c.sensitive, pattern) {
+			c.sensitive = append(c.sensitive, pattern)
+		}
+	}
+}
+
+// IsSensitive check the key is sensitive. see AddSensitive()
+func (c *Config) IsSensitive(key string) bool {
+	if c.root != nil {
+		return c.root.IsSensitive(c.viewKey(key))
+	}
+
+	sep := string(c.opts.Delimiter)
+	if key = formatKey(key, sep); key == "" {
+		return false
+	}
+
+	if c.matchSensitive(key) {
+		return true
+	}
+
+	if !c.opts.Readonly {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+	}
+
+	raw, ok := findByKeys(c.data, strings.Split(key, sep))
+	return ok && c.isSensitiveRaw(raw, 0)
+}
+
+// check the key path matches the sensitive patterns
+func (c *Config) matchSensitive(key string) bool {
+	c.sensLock.RLock()
+	defer c.sensLock.RUnlock()
+
+	if len(c.sensitive) == 0 {
+		return false
+	}
+
+	key = strings.ToLower(key)
+	for _, pattern := range c.sensitive {
+		if wildcardMatch(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// check the raw value is sensitive: encrypted, resolved by sensitive resolver,
+// or referenced a sensitive key.
+func (c *Config) isSensitiveRaw(val interface{}, depth int) bool {
+	str, ok := val.(string)
+	if !ok {
+		return false
+	}
+
+	if IsEncrypted(str) {
+		return true
+	}
+
+	// limit depth for avoid circular reference
+	if depth > 8 || !strings.Contains(str, "${") {
+		return false
+	}
+
+	sep := string(c.opts.Delimiter)
+	for _, expr := range refExprs(str) {
+		name := strings.TrimSpace(strings.SplitN(expr, "|", 2)[0])
+		if scheme, _, isExpr := parseResolverExpr(name); isExpr {
+			if rs := c.getResolver(scheme); rs != nil {
+				if rs.opts.Sensitive {
+					return true
+				}
+				continue
+			}
+		}
+
+		if !c.opts.ParseRefs {
+			continue
+		}
+
+		if name = formatKey(name, sep); name == "" {
+			continue
+		}
+
+		if c.matchSensitive(name) {
+			return true
+		}
+
+		if raw, found := findByKeys(c.data, strings.Split(name, sep)); found && c.isSensitiveRaw(raw, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact the sensitive values in the data, will return new data.
+// raw is the data before resolve references, for check the value origin.
+func (c *Config) redactData(raw, data map[string]interface{}) map[string]interface{} {
+	val := c.walkSensitive("", raw, data, func(val interface{}) interface{} {
+		// the encrypted value is safe to dump
+		if str, ok := val.(string); ok && IsEncrypted(str) {
+			return str
+		}
+		return RedactedValue
+	})
+	return val.(map[string]interface{})
+}
+
+// redact the sensitive values of the data in the error message.
+func (c *Config) redactDataError(key string, data interface{}, err error) error {
+	var values []string
+	raw := data
+	if key != "" {
+		if !c.opts.Readonly {
+			c.lock.RLock()
+		}
+		raw, _ = findByKeys(c.data, strings.Split(key, string(c.opts.Delimiter)))
+		if !c.opts.Readonly {
+			c.lock.RUnlock()
+		}
+	}
+
+	c.walkSensitive(key, raw, data, func(val interface{}) interface{} {
+		collectScalars(val, &values)
+		return val
+	})
+	return redactError(err, values...)
+}
+
+// record error, will redact the value in the error message if the key is sensitive.
+func (c *Config) addValueError(key, val string, err error) {
+	if c.IsSensitive(key) {
+		err = redactError(err, val)
+	}
+	c.addError(err)
+}
+
+// walk the data, call fn for the sensitive values and use the returned value.
+// map and slice value will be copied.
+func (c *Config) walkSensitive(key string, raw, val interface{}, fn func(val interface{}) interface{}) interface{} {
+	if key != "" && (c.matchSensitive(key) || c.isSensitiveRaw(raw, 0)) {
+		return fn(val)
+	}
+
+	sep := string(c.opts.Delimiter)
+	subKey := func(k string) string {
+		if key == "" {
+			return k
+		}
+		return key + sep + k
+	}
+
+	switch typVal := val.(type) {
+	case map[string]interface{}:
+		mp := make(map[string]interface{}, len(typVal))
+		for k, v := range typVal {
+			mp[k] = c.walkSensitive(subKey(k), childValue(raw, k), v, fn)
+		}
+		return mp
+	case map[interface{}]interface{}:
+		mp := make(map[interface{}]interface{}, len(typVal))
+		for k, v := range typVal {
+			sk := strutil.MustString(k)
+			mp[k] = c.walkSensitive(subKey(sk), childValue(raw, sk), v, fn)
+		}
+		return mp
+	case []interface{}:
+		arr := make([]interface{}, len(typVal))
+		for i, v := range typVal {
+			sk := strconv.Itoa(i)
+			arr[i] = c.walkSensitive(subKey(sk), childValue(raw, sk), v, fn)
+		}
+		return arr
+	}
+	return val
+}
+
+// mark the fields with tag `secret:"true"` as sensitive keys.
+func (c *Config) markSecretFields(key string, dst interface{}, tagName string) {
+	typ := reflect.TypeOf(dst)
+	if typ == nil {
+		return
+	}
+
+	var patterns []string
+	collectSecretFields(key, string(c.opts.Delimiter), typ, tagName, &patterns, 0)
+	if len(patterns) > 0 {
+		c.AddSensitive(patterns...)
+	}
+}
+
+func collectSecretFields(key, sep string, typ reflect.Type, tagName string, out *[]string, depth int) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct || depth > 8 {
+		return
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		name := field.Name
+		tagVal := field.Tag.Get(tagName)
+		if tagVal == "-" {
+			continue
+		}
+
+		nodes := strings.Split(tagVal, ",")
+		if nodes[0] != "" {
+			name = nodes[0]
+		}
+
+		fieldKey := key
+		if !arrutil.StringsHas(nodes[1:], "squash") {
+			if fieldKey != "" {
+				fieldKey += sep
+			}
+			fieldKey += name
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			*out = append(*out, fieldKey)
+			continue
+		}
+		collectSecretFields(fieldKey, sep, field.Type, tagName, out, depth+1)
+	}
+}
+
+// redactedError an error with the sensitive values masked in the message
+type redactedError struct {
+	msg string
+	err error
+}
+
+// Error message
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap the raw error
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// mask the values in the error message
+func redactError(err error, values ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	// replace the longer values first, the value may contains others.
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	msg := err.Error()
+	for _, val := range values {
+		if val != "" {
+			msg = strings.Replace(msg, val, RedactedValue, -1)
+		}
+	}
+
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+// collect the scalar values in the value, for mask them in error message.
+func collectScalars(val interface{}, out *[]string) {
+	switch typVal := val.(type) {
+	case nil:
+	case map[string]interface{}:
+		for _, v := range typVal {
+			collectScalars(v, out)
+		}
+	case map[interface{}]interface{}:
+		for _, v := range typVal {
+			collectScalars(v, out)
+		}
+	case []interface{}:
+		for _, v := range typVal {
+			collectScalars(v, out)
+		}
+	default:
+		if str, err := strutil.AnyToString(val, false); err == nil {
+			*out = append(*out, str)
+		}
+	}
+}
+
+// get the child value of the map or slice by key
+func childValue(val interface{}, key string) interface{} {
+	switch typVal := val.(type) {
+	case map[string]interface{}:
+		return typVal[key]
+	case map[interface{}]interface{}:
+		return typVal[key]
+	case []interface{}:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(typVal) {
+			return typVal[i]
+		}
+	}
+	return nil
+}
+
+// get the reference expressions in the string. eg: "${db.host}:${db.port}" => ["db.host", "db.port"]
+func refExprs(str string) (exprs []string) {
+	for {
+		pos := strings.Index(str, "${")
+		if pos < 0 {
+			return
+		}
+
+		end := strings.IndexByte(str[pos:], '}')
+		if end < 0 {
+			return
+		}
+
+		// skip escaped by "$${"
+		if pos == 0 || str[pos-1] != '$' {
+			exprs = append(exprs, str[pos+2:pos+end])
+		}
+		str = str[pos+end+1:]
+	}
+}
+
+// match the string by the pattern, "*" matches any chars.
+func wildcardMatch(pattern, str string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == str
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(str, parts[0]) {
+		return false
+	}
+	str = str[len(parts[0]):]
+
+	last := len(parts) - 1
+	for _, part := range parts[1:last] {
+		pos := strings.Index(str, part)
+		if pos < 0 {
+			return false
+		}
+		str = str[pos+len(part):]
+	}
+	return strings.HasSuffix(str, parts[last])
+}
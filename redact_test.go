@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(wildcardMatch("db.password", "db.password"))
+	is.True(wildcardMatch("*.password", "db.password"))
+	is.True(wildcardMatch("*.password", "app.db.password"))
+	is.True(wildcardMatch("*token*", "api_token"))
+	is.True(wildcardMatch("*token*", "token"))
+	is.True(wildcardMatch("a*b*c", "a-b-c"))
+	is.False(wildcardMatch("*.password", "password"))
+	is.False(wildcardMatch("*token*", "api_key"))
+	is.False(wildcardMatch("a*b*b", "ab"))
+}
+
+func TestConfig_AddSensitive(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	err := c.LoadData(map[string]interface{}{
+		"name":      "app",
+		"api_token": "tk-123",
+		"db": map[string]interface{}{
+			"user":     "root",
+			"password": "s3cret",
+		},
+		"tokens": []interface{}{"a1", "b2"},
+	})
+	is.NoError(err)
+
+	c.AddSensitive("*.password", "*TOKEN*")
+	is.True(c.IsSensitive("db.password"))
+	is.True(c.IsSensitive("api_token"))
+	is.False(c.IsSensitive("db.user"))
+	is.False(c.IsSensitive(""))
+
+	// getter is not masked
+	is.Equal("s3cret", c.String("db.password"))
+
+	str := c.ToJSON()
+	is.NotContains(str, "s3cret")
+	is.NotContains(str, "tk-123")
+	is.NotContains(str, "a1")
+	is.Contains(str, `"password":"******"`)
+	is.Contains(str, `"user":"root"`)
+	is.Contains(str, `"name":"app"`)
+
+	buf := new(bytes.Buffer)
+	_, err = c.WriteTo(buf)
+	is.NoError(err)
+	is.NotContains(buf.String(), "s3cret")
+
+	buf.Reset()
+	_, err = c.DumpWith(buf, JSON, RevealSecrets)
+	is.NoError(err)
+	is.Contains(buf.String(), "s3cret")
+
+	// the data is not changed
+	is.Equal("s3cret", c.Data()["db"].(map[string]interface{})["password"])
+
+	// view
+	db := c.Sub("db")
+	is.True(db.IsSensitive("password"))
+	is.NotContains(db.ToJSON(), "s3cret")
+	db.AddSensitive("user")
+	is.True(c.IsSensitive("db.user"))
+	is.Contains(db.ToJSON(), `"user":"******"`)
+
+	// persist the real values to file
+	file := filepath.Join(t.TempDir(), "config.json")
+	is.NoError(c.DumpToFile(file, JSON))
+	c2 := New("test")
+	is.NoError(c2.LoadFiles(file))
+	is.Equal("s3cret", c2.String("db.password"))
+}
+
+func TestConfig_sensitive_errors(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	err := c.LoadData(map[string]interface{}{
+		"port": "not-a-port",
+		"db": map[string]interface{}{
+			"password": "s3cret",
+			"pin":      "s3cret-pin",
+		},
+	})
+	is.NoError(err)
+	c.AddSensitive("db.*")
+
+	is.Equal(0, c.Int("db.pin"))
+	err = c.Error()
+	is.Error(err)
+	is.NotContains(err.Error(), "s3cret-pin")
+	is.Contains(err.Error(), RedactedValue)
+
+	is.False(c.Bool("db.password"))
+	err = c.Error()
+	is.Error(err)
+	is.NotContains(err.Error(), "s3cret")
+
+	is.Equal(0.0, c.Float("db.password"))
+	err = c.Error()
+	is.Error(err)
+	is.NotContains(err.Error(), "s3cret")
+
+	// not sensitive
+	is.Equal(0, c.Int("port"))
+	is.Contains(c.Error().Error(), "not-a-port")
+
+	st := struct {
+		Password string
+		Pin      int
+	}{}
+	err = c.Structure("db", &st)
+	is.Error(err)
+	is.NotContains(err.Error(), "s3cret")
+	is.NotContains(err.Error(), "-pin")
+}
+
+func TestConfig_sensitive_origin(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", ParseRefs, EnableResolvers("base64"))
+	c.AddResolver("vault", func(path string) (string, error) {
+		return "vault-" + path, nil
+	}, SensitiveResolver)
+
+	err := c.LoadData(map[string]interface{}{
+		"name": "${base64:YXBw}",
+		"db": map[string]interface{}{
+			"password": "${vault:db/password}",
+			"dsn":      "root:${db.password}@tcp(localhost)",
+		},
+		"escaped": "$${vault:db/password}",
+	})
+	is.NoError(err)
+
+	is.True(c.IsSensitive("db.password"))
+	is.True(c.IsSensitive("db.dsn"))
+	is.False(c.IsSensitive("name"))
+	is.False(c.IsSensitive("escaped"))
+	is.Equal("vault-db/password", c.String("db.password"))
+
+	str := c.ToJSON()
+	is.NotContains(str, "vault-db/password")
+	is.Contains(str, `"name":"app"`)
+	is.Contains(str, `"dsn":"******"`)
+
+	// the values from built-in file and env resolvers are sensitive
+	secretFile := filepath.Join(t.TempDir(), "token")
+	is.NoError(ioutil.WriteFile(secretFile, []byte("file-token\n"), 0600))
+
+	c = NewWithOptions("test", ParseRefs, EnableResolvers("file", "env", "base64"))
+	is.NoError(c.LoadData(map[string]interface{}{
+		"token": "${file:" + secretFile + "}",
+		"home":  "${env:HOME}",
+		"name":  "${base64:YXBw}",
+	}))
+	is.Equal("file-token", c.String("token"))
+	is.True(c.IsSensitive("token"))
+	is.True(c.IsSensitive("home"))
+	is.False(c.IsSensitive("name"))
+
+	str = c.ToJSON()
+	is.NotContains(str, "file-token")
+	is.Contains(str, `"token":"******"`)
+	is.Contains(str, `"name":"app"`)
+
+	// struct tag
+	type DB struct {
+		User     string `mapstructure:"user"`
+		Password string `secret:"true"`
+	}
+	type App struct {
+		Name string `mapstructure:"name"`
+		DB   DB     `mapstructure:"db"`
+	}
+
+	c = New("test")
+	is.NoError(c.LoadData(map[string]interface{}{
+		"name": "app",
+		"db":   map[string]interface{}{"user": "root", "password": "s3cret"},
+	}))
+	is.False(c.IsSensitive("db.password"))
+
+	app := &App{}
+	is.NoError(c.Structure("", app))
+	is.Equal("s3cret", app.DB.Password)
+	is.True(c.IsSensitive("db.password"))
+	is.False(c.IsSensitive("db.user"))
+	is.NotContains(c.ToJSON(), "s3cret")
+
+	c = New("test")
+	is.NoError(c.LoadData(map[string]interface{}{
+		"db": map[string]interface{}{"user": "root", "password": "s3cret"},
+	}))
+	is.NoError(c.Structure("db", &DB{}))
+	is.True(c.IsSensitive("db.password"))
+}
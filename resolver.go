@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/goutil/envutil"
+)
+
+// ResolverFunc resolve value by the argument.
+//
+// eg: for "${file:/run/secrets/db_password}", the arg is "/run/secrets/db_password"
+type ResolverFunc func(arg string) (string, error)
+
+// ResolverOptions for a value resolver
+type ResolverOptions struct {
+	// Cache the resolved value
+	Cache bool
+	// TTL of the cached value, 0 is never expired.
+	TTL time.Duration
+	// Sensitive the resolved values are sensitive, will be masked on dump data.
+	Sensitive bool
+}
+
+// CacheResolved set resolver option, cache the resolved value with a TTL. 0 is never expired.
+func CacheResolved(ttl time.Duration) func(*ResolverOptions) {
+	return func(opts *ResolverOptions) {
+		opts.Cache = true
+		opts.TTL = ttl
+	}
+}
+
+// SensitiveResolver set resolver option, the resolved values are sensitive. see Config.AddSensitive()
+func SensitiveResolver(opts *ResolverOptions) { opts.Sensitive = true }
+
+// FileResolver read value from file. will trim the trailing newline.
+//
+// Usage: "${file:/run/secrets/db_password}"
+func FileResolver(path string) (string, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bts), "\r\n"), nil
+}
+
+// EnvResolver read value from OS ENV.
+//
+// Usage: "${env:HOME}"
+func EnvResolver(name string) (string, error) {
+	val, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("the ENV var %q is not set", name)
+	}
+	return val, nil
+}
+
+// Base64Resolver decode the base64 encoded value.
+//
+// Usage: "${base64:aGVsbG8=}"
+func Base64Resolver(str string) (string, error) {
+	bts, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return "", err
+	}
+	return string(bts), nil
+}
+
+// built-in resolvers, require enabled by EnableResolvers(). can be overridden by Config.AddResolver()
+//
+// The values from file and env are sensitive, will be masked on dump data.
+var builtinResolvers = map[string]*resolver{
+	"file":   {fn: FileResolver, opts: ResolverOptions{Sensitive: true}},
+	"env":    {fn: EnvResolver, opts: ResolverOptions{Sensitive: true}},
+	"base64": {fn: Base64Resolver},
+}
+
+// EnableResolvers enable the built-in resolvers by the schemes: file, env, base64
+//
+// They are disabled by default, the config data may be from untrusted sources, such as
+// remote, then "${file:/etc/shadow}" can read any files.
+//
+// Usage:
+//
+//	c := config.NewWithOptions("app", config.ParseRefs, config.EnableResolvers("file", "env"))
+func EnableResolvers(schemes ...string) func(*Options) {
+	return func(opts *Options) {
+		opts.Resolvers = append(opts.Resolvers, schemes...)
+	}
+}
+
+// AddResolver add a value resolver for the scheme
+func AddResolver(scheme string, fn ResolverFunc, opts ...func(*ResolverOptions)) {
+	dc.AddResolver(scheme, fn, opts...)
+}
+
+// AddResolver add a value resolver for the scheme.
+// It's used on resolve the value like "${scheme:arg}", require the option ParseRefs or ParseEnv is enabled.
+//
+// There are built-in resolvers: file, env, base64. require enabled by EnableResolvers()
+//
+// Usage:
+//
+//	c.AddResolver("vault", func(path string) (string, error) {
+//		return vaultClient.Read(path)
+//	}, config.CacheResolved(5*time.Minute), config.SensitiveResolver)
+//
+//	// in config file
+//	db_password: "${vault:secret/data/db#password}"
+func (c *Config) AddResolver(scheme string, fn ResolverFunc, opts ...func(*ResolverOptions)) {
+	if c.root != nil {
+		c.root.AddResolver(scheme, fn, opts...)
+		return
+	}
+
+	r := &resolver{fn: fn}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+
+	c.resLock.Lock()
+	if c.resolvers == nil {
+		c.resolvers = make(map[string]*resolver)
+	}
+	c.resolvers[scheme] = r
+	c.resLock.Unlock()
+}
+
+// HasResolver check the resolver exists for the scheme
+func (c *Config) HasResolver(scheme string) bool {
+	return c.getResolver(scheme) != nil
+}
+
+// get resolver by scheme. will fall back to the enabled built-in resolvers.
+func (c *Config) getResolver(scheme string) *resolver {
+	c.resLock.RLock()
+	r, ok := c.resolvers[scheme]
+	c.resLock.RUnlock()
+	if ok {
+		return r
+	}
+
+	if r, ok = builtinResolvers[scheme]; !ok {
+		return nil
+	}
+
+	for _, name := range c.opts.Resolvers {
+		if name == scheme {
+			return r
+		}
+	}
+	return nil
+}
+
+// parse the scheme and argument from the expression. eg: "file:/path/to/file"
+func parseResolverExpr(expr string) (scheme, arg string, ok bool) {
+	pos := strings.IndexByte(expr, ':')
+	if pos < 1 {
+		return
+	}
+
+	scheme = strings.TrimSpace(expr[:pos])
+	return scheme, strings.TrimSpace(expr[pos+1:]), true
+}
+
+// parse the ENV value in the string, will use the resolvers for "${scheme:arg}"
+func (c *Config) parseEnvValue(str string) string {
+	if strings.Contains(str, ":") {
+		r := &refResolver{c: c, sep: string(c.opts.Delimiter), refs: true, onlySchemes: true}
+		str, _ = r.resolveString(str).(string)
+	}
+
+	return envutil.ParseEnvValue(str)
+}
+
+type cachedValue struct {
+	val      string
+	expireAt time.Time
+}
+
+// resolver a registered value resolver
+type resolver struct {
+	fn   ResolverFunc
+	opts ResolverOptions
+
+	lock  sync.Mutex
+	cache map[string]cachedValue
+}
+
+func (r *resolver) resolve(arg string) (string, error) {
+	if !r.opts.Cache {
+		return r.fn(arg)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if cv, ok := r.cache[arg]; ok {
+		if cv.expireAt.IsZero() || time.Now().Before(cv.expireAt) {
+			return cv.val, nil
+		}
+	}
+
+	val, err := r.fn(arg)
+	if err != nil {
+		return "", err
+	}
+
+	cv := cachedValue{val: val}
+	if r.opts.TTL > 0 {
+		cv.expireAt = time.Now().Add(r.opts.TTL)
+	}
+
+	if r.cache == nil {
+		r.cache = make(map[string]cachedValue)
+	}
+	r.cache[arg] = cv
+	return val, nil
+}
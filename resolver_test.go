@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_AddResolver(t *testing.T) {
+	is := assert.New(t)
+
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	is.NoError(ioutil.WriteFile(secretFile, []byte("s3cret\n"), 0600))
+
+	// the built-in resolvers are disabled by default
+	c := NewWithOptions("test", ParseRefs)
+	is.False(c.HasResolver("file"))
+	is.NoError(c.LoadStrings(JSON, `{"pwd": "${file:`+secretFile+`|none}"}`))
+	is.Equal("none", c.String("pwd"))
+
+	c = NewWithOptions("test", ParseRefs, EnableResolvers("file", "env", "base64"))
+	is.True(c.HasResolver("file"))
+	is.False(c.HasResolver("vault"))
+
+	var calls int
+	c.AddResolver("vault", func(path string) (string, error) {
+		calls++
+		if path == "not-exist" {
+			return "", errors.New("secret not found")
+		}
+		return "vault:" + path, nil
+	}, CacheResolved(0))
+	is.True(c.HasResolver("vault"))
+
+	err := c.LoadData(map[string]interface{}{
+		"db": map[string]interface{}{
+			"password": "${file:" + secretFile + "}",
+			"token":    "${vault:secret/db}",
+			"user":     "${base64:cm9vdA==}",
+			"dsn":      "${db.user}:${db.password}@localhost",
+			"other":    "${vault:not-exist|def-val}",
+		},
+		"home": "${env:APP_TEST_HOME}",
+	})
+	is.NoError(err)
+
+	testutil.MockEnvValue("APP_TEST_HOME", "/home/inhere", func(_ string) {
+		is.Equal("/home/inhere", c.String("home"))
+	})
+
+	is.Equal("s3cret", c.String("db.password"))
+	is.Equal("root", c.String("db.user"))
+	is.Equal("root:s3cret@localhost", c.String("db.dsn"))
+	is.Equal("def-val", c.String("db.other"))
+
+	is.Equal("vault:secret/db", c.String("db.token"))
+	is.Equal("vault:secret/db", c.String("db.token"))
+	is.Equal(2, calls) // the error result is not cached
+
+	st := struct {
+		Password string
+		Token    string
+	}{}
+	is.NoError(c.Structure("db", &st))
+	is.Equal("s3cret", st.Password)
+	is.Equal("vault:secret/db", st.Token)
+
+	// error
+	is.NoError(c.Set("db.bad", "${file:/path/to/not-exist}"))
+	is.Equal("${file:/path/to/not-exist}", c.String("db.bad"))
+	err = c.Error()
+	is.Error(err)
+	is.Contains(err.Error(), `"file" resolver`)
+}
+
+func TestConfig_AddResolver_ttl(t *testing.T) {
+	is := assert.New(t)
+
+	var calls int
+	c := NewWithOptions("test", ParseRefs)
+	c.AddResolver("counter", func(string) (string, error) {
+		calls++
+		return "val", nil
+	}, CacheResolved(20*time.Millisecond))
+
+	is.NoError(c.Set("key", "${counter:any}"))
+	c.String("key")
+	c.String("key")
+	is.Equal(1, calls)
+
+	time.Sleep(30 * time.Millisecond)
+	c.String("key")
+	is.Equal(2, calls)
+}
+
+func TestConfig_AddResolver_parseEnv(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", ParseEnv)
+	c.AddResolver("upper", func(arg string) (string, error) {
+		return "UP-" + arg, nil
+	})
+
+	err := c.LoadStrings(JSON, `{"name": "${upper:name}", "home": "${APP_TEST_HOME|/home/def}", "map": {"k": "${upper:v}"}}`)
+	is.NoError(err)
+	is.Equal("UP-name", c.String("name"))
+	is.Equal("/home/def", c.String("home"))
+	is.Equal("UP-v", c.StringMap("map")["k"])
+
+	st := struct{ Name, Home string }{}
+	is.NoError(c.Structure("", &st))
+	is.Equal("UP-name", st.Name)
+	is.Equal("/home/def", st.Home)
+
+	// the built-in resolvers are disabled by default
+	is.NoError(c.Set("b64", "${base64:YXBw}"))
+	is.NotEqual("app", c.String("b64"))
+
+	is.NoError(os.Setenv("APP_TEST_HOME", "/home/inhere"))
+	defer os.Unsetenv("APP_TEST_HOME")
+	is.Equal("/home/inhere", c.String("home"))
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileSource the loaded config file, for write back changes.
+type fileSource struct {
+	path   string
+	format string
+	// the data decoded from the file
+	data map[string]interface{}
+	// the key prefix of the data mounted in config. see IncludeKey
+	prefix string
+}
+
+// WithOverridesFile set the file for save the changed keys which not owned by any loaded file.
+func WithOverridesFile(file string) func(*Options) {
+	return func(opts *Options) {
+		opts.OverridesFile = file
+	}
+}
+
+// ChangedKeys get the keys changed by Set() and not saved yet. see SaveChanges()
+func (c *Config) ChangedKeys() []string {
+	if c.root != nil {
+		return c.root.ChangedKeys()
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.changedKeys...)
+}
+
+// SaveChanges write the changed keys back to the files
+func SaveChanges() error { return dc.SaveChanges() }
+
+// SaveChanges write the keys changed by Set() back to the loaded files.
+//
+// For each changed key, will find the last loaded file which owns the key(or the nearest
+// parent key), and rewrite only the file in its own format. The keys not owned by any
+// file will be written to the OverridesFile. see WithOverridesFile()
+//
+// The files are written atomically, and the file permissions are kept.
+//
+// Usage:
+//
+//	c.LoadFiles("base.yml", "local.toml")
+//	c.Set("db.port", 5433)
+//	err := c.SaveChanges() // only the file which has the "db.port" will be rewritten.
+//
+// NOTICE: the files with multi documents cannot be written back.
+func (c *Config) SaveChanges() (err error) {
+	if c.root != nil {
+		return c.root.SaveChanges()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.changedKeys) == 0 {
+		return nil
+	}
+
+	// group the changed keys by the owner file
+	var owners []*fileSource
+	var noOwner []string
+	groups := make(map[*fileSource][]string)
+	for _, key := range c.changedKeys {
+		src := c.findOwner(key)
+		if src == nil {
+			noOwner = append(noOwner, key)
+			continue
+		}
+
+		if _, ok := groups[src]; !ok {
+			owners = append(owners, src)
+		}
+		groups[src] = append(groups[src], key)
+	}
+
+	if len(noOwner) > 0 {
+		if c.opts.OverridesFile == "" {
+			return fmt.Errorf("config: not found the owner file of the keys %v, please set the OverridesFile", noOwner)
+		}
+
+		src, err := c.overridesSource()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := groups[src]; !ok {
+			owners = append(owners, src)
+		}
+		groups[src] = append(groups[src], noOwner...)
+	}
+
+	saved := make(map[string]bool, len(c.changedKeys))
+	defer func() {
+		var keys []string
+		for _, key := range c.changedKeys {
+			if !saved[key] {
+				keys = append(keys, key)
+			}
+		}
+		c.changedKeys = keys
+	}()
+
+	for _, src := range owners {
+		var written []string
+		written, err = c.saveSource(src, groups[src])
+		for _, key := range written {
+			saved[key] = true
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record the key changed by Set()
+func (c *Config) recordChange(key string) {
+	for _, k := range c.changedKeys {
+		if k == key {
+			return
+		}
+	}
+	c.changedKeys = append(c.changedKeys, key)
+}
+
+// find the last loaded file which owns the key or the nearest parent key.
+func (c *Config) findOwner(key string) *fileSource {
+	keys := strings.Split(key, string(c.opts.Delimiter))
+
+	var owner *fileSource
+	var depth int
+	for i := len(c.sources) - 1; i >= 0; i-- {
+		src := c.sources[i]
+
+		// the data is mounted at the prefix. see IncludeKey
+		skip := 0
+		if src.prefix != "" {
+			if !strings.HasPrefix(key, src.prefix+string(c.opts.Delimiter)) {
+				continue
+			}
+			skip = strings.Count(src.prefix, string(c.opts.Delimiter)) + 1
+		}
+
+		for n := len(keys); n > depth && n > skip; n-- {
+			if _, ok := findByKeys(src.data, keys[skip:n]); ok {
+				owner, depth = src, n
+				break
+			}
+		}
+	}
+	return owner
+}
+
+// get the source of the overrides file. will load the file data if it exists.
+func (c *Config) overridesSource() (*fileSource, error) {
+	path := c.opts.OverridesFile
+	for _, src := range c.sources {
+		if src.path == path {
+			return src, nil
+		}
+	}
+
+	format := fixFormat(strings.Trim(filepath.Ext(path), "."))
+	src := &fileSource{path: path, format: format, data: make(map[string]interface{})}
+
+	bts, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(bts) > 0 {
+		docs, err := c.decodeFile(path, format, bts)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(docs) > 1 {
+			return nil, fmt.Errorf("config: the overrides file %q cannot have multi documents", path)
+		}
+
+		if len(docs) == 1 {
+			src.data = docs[0]
+		}
+	}
+
+	c.sources = append(c.sources, src)
+	return src, nil
+}
+
+// apply the changed keys to the file data, and write to the file. returns the written keys.
+//
+// The changed keys without value in the runtime layer are not written, and will return error.
+// eg: the runtime layer is removed by RemoveLayer()
+func (c *Config) saveSource(src *fileSource, keys []string) (written []string, err error) {
+	encode := c.fileEncoder(src.path, src.format)
+	if encode == nil {
+		return nil, fmt.Errorf("config: not exists or not register encoder for the format: %s", src.format)
+	}
+
+	sep := c.opts.Delimiter
+	runtime := c.layers[LayerRuntime]
+
+	data := deepCopyMap(src.data)
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	var setKeys, missing []string
+	for _, key := range keys {
+		val, ok := findByKeys(runtime, strings.Split(key, string(sep)))
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		fileKey := key
+		if src.prefix != "" {
+			fileKey = strings.TrimPrefix(key, src.prefix+string(sep))
+		}
+
+		if err = setValue(data, fileKey, deepCopyValue(val), sep, true); err != nil {
+			return nil, err
+		}
+		setKeys = append(setKeys, key)
+	}
+
+	if len(setKeys) > 0 {
+		if err = c.writeSource(src, data, encode); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(missing) > 0 {
+		err = fmt.Errorf("config: the changed keys %v have no value in the runtime layer", missing)
+	}
+	return setKeys, err
+}
+
+// encode the data and write to the file of the source
+func (c *Config) writeSource(src *fileSource, data map[string]interface{}, encode Encoder) (err error) {
+	out := data
+	if len(c.encryptKeys) > 0 {
+		if out, err = c.encryptData(data); err != nil {
+			return err
+		}
+	}
+
+	bts, err := encode(out)
+	if err != nil {
+		return err
+	}
+
+	wo := &writeFileOptions{perm: c.fileModeFor(data), keepPerm: true}
+	if err = writeFileSafe(src.path, bts, wo); err != nil {
+		return err
+	}
+
+	src.data = data
+	return nil
+}
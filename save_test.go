@@ -0,0 +1,158 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_SaveChanges(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	baseFile := filepath.Join(dir, "base.json")
+	localFile := filepath.Join(dir, "local.json")
+	is.NoError(ioutil.WriteFile(baseFile, []byte(`{"name": "app", "db": {"host": "localhost", "port": 5432}}`), 0600))
+	is.NoError(ioutil.WriteFile(localFile, []byte(`{"db": {"host": "127.0.0.1"}}`), 0644))
+
+	c := New("test")
+	is.NoError(c.LoadFiles(baseFile, localFile))
+	is.NoError(c.SaveChanges())
+
+	is.NoError(c.Set("db.port", 5433))
+	is.NoError(c.Set("db.host", "db.local"))
+	is.NoError(c.Set("db.user", "admin"))
+	is.Equal([]string{"db.port", "db.host", "db.user"}, c.ChangedKeys())
+	is.NoError(c.SetDefault("debug", true))
+	is.Len(c.ChangedKeys(), 3)
+
+	is.NoError(c.SaveChanges())
+	is.Empty(c.ChangedKeys())
+
+	bts, err := ioutil.ReadFile(baseFile)
+	is.NoError(err)
+	is.JSONEq(`{"name": "app", "db": {"host": "localhost", "port": 5433}}`, string(bts))
+
+	// the last loaded file owns the "db.host" and "db.user"
+	bts, err = ioutil.ReadFile(localFile)
+	is.NoError(err)
+	is.JSONEq(`{"db": {"host": "db.local", "user": "admin"}}`, string(bts))
+
+	// keep permissions
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(baseFile)
+		is.NoError(err)
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// reload
+	c2 := New("test")
+	is.NoError(c2.LoadFiles(baseFile, localFile))
+	is.Equal(5433, c2.Int("db.port"))
+	is.Equal("db.local", c2.String("db.host"))
+
+	// no owner
+	is.NoError(c.Set("log.level", "debug"))
+	err = c.SaveChanges()
+	is.Error(err)
+	is.Contains(err.Error(), "log.level")
+	is.Equal([]string{"log.level"}, c.ChangedKeys())
+
+	// the changed key without value is not marked saved
+	c = New("test")
+	is.NoError(c.LoadFiles(baseFile))
+	is.NoError(c.Set("db.port", 5434))
+	is.NoError(c.Set("name", "my-app"))
+	is.NoError(c.RemoveLayer(LayerRuntime))
+	is.NoError(c.Set("name", "new-app"))
+
+	err = c.SaveChanges()
+	is.Error(err)
+	is.Contains(err.Error(), "[db.port]")
+	is.Equal([]string{"db.port"}, c.ChangedKeys())
+
+	bts, err = ioutil.ReadFile(baseFile)
+	is.NoError(err)
+	is.JSONEq(`{"name": "new-app", "db": {"host": "localhost", "port": 5433}}`, string(bts))
+}
+
+func TestConfig_SaveChanges_overrides(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	baseFile := filepath.Join(dir, "base.json")
+	overFile := filepath.Join(dir, "overrides.json")
+	is.NoError(ioutil.WriteFile(baseFile, []byte(`{"name": "app"}`), 0644))
+	is.NoError(ioutil.WriteFile(overFile, []byte(`{"env": "dev"}`), 0644))
+
+	c := NewWithOptions("test", WithOverridesFile(overFile))
+	is.NoError(c.LoadFiles(baseFile))
+
+	is.NoError(c.Set("name", "my-app"))
+	is.NoError(c.Set("log.level", "debug"))
+	is.NoError(c.Sub("log").Set("file", "app.log"))
+	is.NoError(c.SaveChanges())
+
+	bts, err := ioutil.ReadFile(baseFile)
+	is.NoError(err)
+	is.JSONEq(`{"name": "my-app"}`, string(bts))
+
+	bts, err = ioutil.ReadFile(overFile)
+	is.NoError(err)
+	is.JSONEq(`{"env": "dev", "log": {"level": "debug", "file": "app.log"}}`, string(bts))
+
+	// save again
+	is.NoError(c.Set("log.level", "info"))
+	is.NoError(c.SaveChanges())
+	bts, err = ioutil.ReadFile(overFile)
+	is.NoError(err)
+	is.JSONEq(`{"env": "dev", "log": {"level": "info", "file": "app.log"}}`, string(bts))
+
+	// not exists overrides file
+	newFile := filepath.Join(dir, "new.json")
+	c = NewWithOptions("test", WithOverridesFile(newFile))
+	is.NoError(c.Set("name", "app"))
+	is.NoError(c.SaveChanges())
+	bts, err = ioutil.ReadFile(newFile)
+	is.NoError(err)
+	is.JSONEq(`{"name": "app"}`, string(bts))
+
+	// not exists encoder
+	c = NewWithOptions("test", WithOverridesFile(filepath.Join(dir, "over.toml")))
+	is.NoError(c.Set("name", "app"))
+	is.Error(c.SaveChanges())
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	is.NoError(writeFileAtomic(file, []byte(`{}`), 0640, true))
+
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+	is.Equal(`{}`, string(bts))
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(file)
+		is.NoError(err)
+		is.Equal(os.FileMode(0640), fi.Mode().Perm())
+	}
+
+	// no temp files left
+	files, err := ioutil.ReadDir(filepath.Dir(file))
+	is.NoError(err)
+	is.Len(files, 1)
+
+	is.Error(writeFileAtomic(filepath.Join(file, "sub.json"), []byte(`{}`), 0644, true))
+
+	// no lock files left
+	is.NoError(writeFileSafe(file, []byte(`{"a": 1}`), &writeFileOptions{perm: 0644}))
+	files, err = ioutil.ReadDir(filepath.Dir(file))
+	is.NoError(err)
+	is.Len(files, 1)
+}
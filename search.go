@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// LoadFirstFound load the first found config file by name in the paths
+func LoadFirstFound(name string, paths ...string) (string, error) {
+	return dc.LoadFirstFound(name, paths...)
+}
+
+// LoadFirstFound search the config file by name in the paths, and load the first found file.
+// returns the loaded file path.
+//
+// If the name has no ext, will try the ext of each registered driver. eg: "app" will
+// try "app.json", "app.yaml", "app.yml", "app.toml" ... in each path. The paths are in
+// priority order from high to low, and allow use "~" and ENV vars. eg: "$HOME/.app"
+//
+// Usage:
+//
+//	paths := append([]string{".", "./config"}, config.XDGConfigDirs("app")...)
+//	file, err := c.LoadFirstFound("app", append(paths, "/etc/app")...)
+//
+//	// see the checked files on not found
+//	fmt.Println(c.SearchedFiles())
+func (c *Config) LoadFirstFound(name string, paths ...string) (string, error) {
+	files := c.searchFiles(name, paths, true)
+	if len(files) == 0 {
+		return "", c.notFoundError(name)
+	}
+	return files[0], c.LoadFiles(files[0])
+}
+
+// LoadAllFound load all found config files by name in the paths
+func LoadAllFound(name string, paths ...string) ([]string, error) {
+	return dc.LoadAllFound(name, paths...)
+}
+
+// LoadAllFound search the config files by name in the paths, and load all found files.
+// returns the loaded file paths.
+//
+// The paths are in priority order from high to low, so the files are loaded in reverse
+// order, the file in the first path will override others. see LoadFirstFound()
+//
+// Usage:
+//
+//	// /etc/app/app.yaml < ~/.config/app/app.yaml < ./app.yaml
+//	files, err := c.LoadAllFound("app", ".", config.XDGConfigHome("app"), "/etc/app")
+func (c *Config) LoadAllFound(name string, paths ...string) ([]string, error) {
+	files := c.searchFiles(name, paths, false)
+	if len(files) == 0 {
+		return nil, c.notFoundError(name)
+	}
+
+	loaded := make([]string, 0, len(files))
+	for i := len(files) - 1; i >= 0; i-- {
+		loaded = append(loaded, files[i])
+	}
+	return loaded, c.LoadFiles(loaded...)
+}
+
+// SearchedFiles get the candidate files checked by the last search.
+// see LoadFirstFound(), LoadAllFound()
+func (c *Config) SearchedFiles() []string {
+	return c.searchedFiles
+}
+
+// XDGConfigHome get the user config dir of the app by XDG rules. default is "~/.config/app"
+func XDGConfigHome(app string) string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(dir, app)
+}
+
+// XDGConfigDirs get the config dirs of the app by XDG rules, ordered by priority
+// from high to low. the user config dir is the first, then the dirs in XDG_CONFIG_DIRS.
+//
+// eg: ["~/.config/app", "/etc/xdg/app"]
+func XDGConfigDirs(app string) []string {
+	dirs := []string{XDGConfigHome(app)}
+
+	sysDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if sysDirs == "" {
+		if runtime.GOOS == "windows" {
+			return dirs
+		}
+		sysDirs = "/etc/xdg"
+	}
+
+	for _, dir := range filepath.SplitList(sysDirs) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, app))
+		}
+	}
+	return dirs
+}
+
+// WalkUpDirs get the dir and all parent dirs of it, ordered from the dir to the root.
+// if dir is empty, will use the current working dir.
+//
+// Usage:
+//
+//	// find the ".app.yaml" in the cwd or parent dirs
+//	file, err := c.LoadFirstFound(".app", config.WalkUpDirs("")...)
+func WalkUpDirs(dir string) []string {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+// search the config files by name in the paths, will record the checked files.
+func (c *Config) searchFiles(name string, paths []string, first bool) []string {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	names := c.candidateNames(name)
+	c.searchedFiles = nil
+
+	var found []string
+	for _, dir := range paths {
+		dir = expandPath(dir)
+		for _, fileName := range names {
+			file := filepath.Join(dir, fileName)
+			c.searchedFiles = append(c.searchedFiles, file)
+
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				found = append(found, file)
+				if first {
+					return found
+				}
+			}
+		}
+	}
+	return found
+}
+
+// get the candidate file names by the registered driver formats.
+func (c *Config) candidateNames(name string) []string {
+	if ext := strings.Trim(filepath.Ext(name), "."); ext != "" && c.HasDecoder(ext) {
+		return []string{name}
+	}
+
+	formats := c.DriverNames()
+	if c.HasDecoder(JSON) {
+		formats = append([]string{JSON}, formats...)
+	}
+
+	names := make([]string, 0, len(formats)+1)
+	seen := make(map[string]bool, len(formats))
+	for _, format := range formats {
+		exts := []string{format}
+		if format == Yaml {
+			exts = append(exts, Yml)
+		}
+
+		for _, ext := range exts {
+			if !seen[ext] && c.HasDecoder(ext) {
+				seen[ext] = true
+				names = append(names, name+"."+ext)
+			}
+		}
+	}
+	return names
+}
+
+func (c *Config) notFoundError(name string) error {
+	return fmt.Errorf("config: not found the config file %q, checked files: %s", name, strings.Join(c.searchedFiles, ", "))
+}
+
+// expand the "~" and ENV vars in the path
+func expandPath(path string) string {
+	if path == "" {
+		return "."
+	}
+
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = homeDir() + path[1:]
+	}
+	return path
+}
+
+func homeDir() string {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
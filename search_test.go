@@ -0,0 +1,192 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// the JSON content is valid YAML, use JSON codec for avoid import cycle
+var yamlDriver = NewDriver(Yaml, JSONDecoder, JSONEncoder)
+
+func TestConfig_LoadFirstFound(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	etc := filepath.Join(dir, "etc")
+	is.NoError(os.MkdirAll(local, 0755))
+	is.NoError(os.MkdirAll(etc, 0755))
+
+	is.NoError(ioutil.WriteFile(filepath.Join(local, "app.yml"), []byte(`{"name": "local-yml"}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(etc, "app.json"), []byte(`{"name": "etc-json"}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(etc, "app.yaml"), []byte(`{"name": "etc-yaml"}`), 0600))
+
+	c := New("test")
+	c.AddDriver(yamlDriver)
+
+	file, err := c.LoadFirstFound("app", local, etc)
+	is.NoError(err)
+	is.Equal(filepath.Join(local, "app.yml"), file)
+	is.Equal("local-yml", c.String("name"))
+	is.Equal([]string{
+		filepath.Join(local, "app.json"),
+		filepath.Join(local, "app.yaml"),
+		filepath.Join(local, "app.yml"),
+	}, c.SearchedFiles())
+
+	// JSON is tried first in the same dir
+	c = New("test")
+	c.AddDriver(yamlDriver)
+	file, err = c.LoadFirstFound("app", filepath.Join(dir, "not-exists"), etc)
+	is.NoError(err)
+	is.Equal(filepath.Join(etc, "app.json"), file)
+	is.Equal("etc-json", c.String("name"))
+	is.Len(c.SearchedFiles(), 4)
+
+	// name with ext
+	c = New("test")
+	c.AddDriver(yamlDriver)
+	file, err = c.LoadFirstFound("app.yaml", local, etc)
+	is.NoError(err)
+	is.Equal(filepath.Join(etc, "app.yaml"), file)
+	is.Equal("etc-yaml", c.String("name"))
+
+	// without yaml driver
+	c = New("test")
+	file, err = c.LoadFirstFound("app", local, etc)
+	is.NoError(err)
+	is.Equal(filepath.Join(etc, "app.json"), file)
+	is.Equal([]string{filepath.Join(local, "app.json"), filepath.Join(etc, "app.json")}, c.SearchedFiles())
+
+	// not found
+	c = New("test")
+	_, err = c.LoadFirstFound("other", local)
+	is.Error(err)
+	is.Contains(err.Error(), `not found the config file "other"`)
+	is.Contains(err.Error(), filepath.Join(local, "other.json"))
+	is.True(c.IsEmpty())
+
+	// dir is not a file
+	is.NoError(os.MkdirAll(filepath.Join(dir, "sub", "app.json"), 0755))
+	_, err = c.LoadFirstFound("app", filepath.Join(dir, "sub"))
+	is.Error(err)
+
+	// package level
+	file, err = LoadFirstFound("app", etc)
+	is.NoError(err)
+	is.Equal(filepath.Join(etc, "app.json"), file)
+	is.Equal("etc-json", String("name"))
+	ClearAll()
+}
+
+func TestConfig_LoadAllFound(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local")
+	etc := filepath.Join(dir, "etc")
+	is.NoError(os.MkdirAll(local, 0755))
+	is.NoError(os.MkdirAll(etc, 0755))
+
+	is.NoError(ioutil.WriteFile(filepath.Join(local, "app.yaml"), []byte(`{"name": "local"}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(etc, "app.json"), []byte(`{"name": "etc", "port": 80}`), 0600))
+	is.NoError(ioutil.WriteFile(filepath.Join(etc, "app.yaml"), []byte(`{"port": 8080, "debug": true}`), 0600))
+
+	c := New("test")
+	c.AddDriver(yamlDriver)
+
+	files, err := c.LoadAllFound("app", local, filepath.Join(dir, "not-exists"), etc)
+	is.NoError(err)
+	is.Equal([]string{
+		filepath.Join(etc, "app.yaml"),
+		filepath.Join(etc, "app.json"),
+		filepath.Join(local, "app.yaml"),
+	}, files)
+	is.Len(c.SearchedFiles(), 9)
+
+	is.Equal("local", c.String("name"))
+	is.Equal(80, c.Int("port"))
+	is.True(c.Bool("debug"))
+
+	// not found
+	_, err = c.LoadAllFound("other", local, etc)
+	is.Error(err)
+
+	// package level
+	files, err = LoadAllFound("app", etc)
+	is.NoError(err)
+	is.Equal([]string{filepath.Join(etc, "app.json")}, files)
+	ClearAll()
+}
+
+func TestXDGConfigDirs(t *testing.T) {
+	is := assert.New(t)
+
+	testutil.MockEnvValues(map[string]string{
+		"XDG_CONFIG_HOME": "/home/inhere/.xdg",
+		"XDG_CONFIG_DIRS": "/etc/xdg:/usr/local/etc/xdg",
+	}, func() {
+		is.Equal("/home/inhere/.xdg/app", XDGConfigHome("app"))
+		is.Equal([]string{
+			"/home/inhere/.xdg/app",
+			"/etc/xdg/app",
+			"/usr/local/etc/xdg/app",
+		}, XDGConfigDirs("app"))
+	})
+
+	testutil.MockEnvValues(map[string]string{
+		"XDG_CONFIG_HOME": "",
+		"XDG_CONFIG_DIRS": "",
+		"HOME":            "/home/inhere",
+	}, func() {
+		is.Equal("/home/inhere/.config/app", XDGConfigHome("app"))
+		is.Equal([]string{"/home/inhere/.config/app", "/etc/xdg/app"}, XDGConfigDirs("app"))
+	})
+}
+
+func TestWalkUpDirs(t *testing.T) {
+	is := assert.New(t)
+
+	dirs := WalkUpDirs("/tmp/a/b")
+	is.Equal([]string{"/tmp/a/b", "/tmp/a", "/tmp", "/"}, dirs)
+
+	wd, err := os.Getwd()
+	is.NoError(err)
+	dirs = WalkUpDirs("")
+	is.Equal(wd, dirs[0])
+	is.Equal("/", dirs[len(dirs)-1])
+
+	// find file in the parent dir
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	is.NoError(os.MkdirAll(sub, 0755))
+	is.NoError(ioutil.WriteFile(filepath.Join(root, ".app.json"), []byte(`{"name": "root"}`), 0600))
+
+	c := New("test")
+	file, err := c.LoadFirstFound(".app", WalkUpDirs(sub)...)
+	is.NoError(err)
+	is.Equal(filepath.Join(root, ".app.json"), file)
+	is.Equal("root", c.String("name"))
+	is.Len(c.SearchedFiles(), 3)
+}
+
+func TestExpandPath(t *testing.T) {
+	is := assert.New(t)
+
+	testutil.MockEnvValues(map[string]string{
+		"HOME":         "/home/inhere",
+		"TEST_APP_DIR": "/opt/app",
+	}, func() {
+		is.Equal(".", expandPath(""))
+		is.Equal("/home/inhere", expandPath("~"))
+		is.Equal("/home/inhere/.app", expandPath("~/.app"))
+		is.Equal("/opt/app/config", expandPath("$TEST_APP_DIR/config"))
+		is.Equal("/home/inhere/.app", expandPath("${HOME}/.app"))
+		is.Equal("~other/.app", expandPath("~other/.app"))
+	})
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +15,15 @@ import (
 
 // ValDecodeHookFunc returns a mapstructure.DecodeHookFunc that parse ENV var, and more custom parse
 func ValDecodeHookFunc(parseEnv, parseTime bool) mapstructure.DecodeHookFunc {
+	var envParser func(string) string
+	if parseEnv {
+		envParser = envutil.ParseEnvValue
+	}
+	return newValDecodeHook(envParser, parseTime)
+}
+
+// create a decode hook func. if parseEnv is not nil, will use it parse ENV value.
+func newValDecodeHook(parseEnv func(string) string, parseTime bool) mapstructure.DecodeHookFunc {
 	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
 		if f.Kind() != reflect.String {
 			return data, nil
@@ -31,8 +43,8 @@ func ValDecodeHookFunc(parseEnv, parseTime bool) mapstructure.DecodeHookFunc {
 					return dur, nil
 				}
 			}
-		} else if parseEnv { // parse ENV value
-			str = envutil.ParseEnvValue(str)
+		} else if parseEnv != nil { // parse ENV value
+			str = parseEnv(str)
 		}
 
 		return str, nil
@@ -55,7 +67,7 @@ func SetDecoder(format string, decoder Decoder) {
 // Deprecated: please use driver instead
 func (c *Config) SetDecoder(format string, decoder Decoder) {
 	format = fixFormat(format)
-	c.decoders[format] = decoder
+	c.rootConfig().decoders[format] = decoder
 }
 
 // SetDecoders set decoders
@@ -79,7 +91,7 @@ func SetEncoder(format string, encoder Encoder) {
 // Deprecated: please use driver instead
 func (c *Config) SetEncoder(format string, encoder Encoder) {
 	format = fixFormat(format)
-	c.encoders[format] = encoder
+	c.rootConfig().encoders[format] = encoder
 }
 
 // SetEncoders set encoders
@@ -136,6 +148,114 @@ func parseVarNameAndType(key string) (string, string) {
 	return key, typ
 }
 
+// find value from the data by key paths
+func findByKeys(data map[string]interface{}, keys []string) (item interface{}, ok bool) {
+	if item, ok = data[keys[0]]; !ok {
+		return
+	}
+
+	for _, k := range keys[1:] {
+		switch typeData := item.(type) {
+		case map[string]interface{}:
+			item, ok = typeData[k]
+		case map[interface{}]interface{}:
+			item, ok = typeData[k]
+		case map[string]string:
+			item, ok = typeData[k]
+		case map[string]int:
+			item, ok = typeData[k]
+		case []interface{}:
+			i, err := strconv.Atoi(k)
+			if ok = err == nil && i >= 0 && i < len(typeData); ok {
+				item = typeData[i]
+			}
+		case []string:
+			i, err := strconv.Atoi(k)
+			if ok = err == nil && i >= 0 && i < len(typeData); ok {
+				item = typeData[i]
+			}
+		case []int:
+			i, err := strconv.Atoi(k)
+			if ok = err == nil && i >= 0 && i < len(typeData); ok {
+				item = typeData[i]
+			}
+		default:
+			ok = false
+		}
+
+		if !ok {
+			return nil, false
+		}
+	}
+	return
+}
+
+// delete the value from the data by key paths
+func deleteByKeys(data map[string]interface{}, keys []string) {
+	if len(keys) == 1 {
+		delete(data, keys[0])
+		return
+	}
+
+	sub := toStringMap(data[keys[0]])
+	if sub == nil {
+		return
+	}
+
+	deleteByKeys(sub, keys[1:])
+	data[keys[0]] = sub
+}
+
+// deep copy a map data
+func deepCopyMap(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	mp := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		mp[k] = deepCopyValue(v)
+	}
+	return mp
+}
+
+// deep copy the map and slice value
+func deepCopyValue(val interface{}) interface{} {
+	switch typVal := val.(type) {
+	case map[string]interface{}:
+		return deepCopyMap(typVal)
+	case map[interface{}]interface{}:
+		mp := make(map[interface{}]interface{}, len(typVal))
+		for k, v := range typVal {
+			mp[k] = deepCopyValue(v)
+		}
+		return mp
+	case []interface{}:
+		arr := make([]interface{}, len(typVal))
+		for i, v := range typVal {
+			arr[i] = deepCopyValue(v)
+		}
+		return arr
+	case map[string]string:
+		mp := make(map[string]string, len(typVal))
+		for k, v := range typVal {
+			mp[k] = v
+		}
+		return mp
+	case map[string]int:
+		mp := make(map[string]int, len(typVal))
+		for k, v := range typVal {
+			mp[k] = v
+		}
+		return mp
+	case []string:
+		return append([]string(nil), typVal...)
+	case []int:
+		return append([]int(nil), typVal...)
+	}
+	return val
+}
+
 // format key
 func formatKey(key, sep string) string {
 	return strings.Trim(strings.TrimSpace(key), sep)
@@ -147,6 +267,14 @@ func fixFormat(f string) string {
 		f = Yaml
 	}
 
+	if f == "jsonl" {
+		f = NDJSON
+	}
+
+	if f == "dotenv" {
+		f = DotEnv
+	}
+
 	if f == "inc" {
 		f = Ini
 	}
@@ -157,3 +285,108 @@ func fixFormat(f string) string {
 	}
 	return f
 }
+
+// writeFileOptions for write file safely
+type writeFileOptions struct {
+	// the mode for the new file
+	perm os.FileMode
+	// keep the mode of the existing file, but no wider than the perm
+	keepPerm bool
+	// the number of backups of the previous file
+	backups int
+}
+
+// write data to the file safely: hold the advisory lock, backup the previous file, and write atomically.
+func writeFileSafe(path string, data []byte, wo *writeFileOptions) error {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if wo.backups > 0 {
+		if err = rotateBackups(path, wo.backups, wo.perm); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, data, wo.perm, wo.keepPerm)
+}
+
+// write data to the file atomically: write to a temp file in the same dir, fsync, rename it, then fsync the dir.
+// if keepPerm is true, will keep the mode of the existing file, the bits not in the perm are dropped.
+// eg: the existing mode 0777, perm 0644 => 0644. the existing mode 0640, perm 0644 => 0640
+func writeFileAtomic(path string, data []byte, perm os.FileMode, keepPerm bool) (err error) {
+	if keepPerm {
+		if fi, statErr := os.Stat(path); statErr == nil {
+			perm &= fi.Mode().Perm()
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// sync the dir for persist the rename
+	return syncDir(filepath.Dir(path))
+}
+
+// backup the file to "path.bak.1", and rotate the old backups. keep max num backups.
+// the backup keeps the mode of the file, but no wider than the perm.
+func rotateBackups(path string, num int, perm os.FileMode) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	bakName := func(i int) string {
+		return path + ".bak." + strconv.Itoa(i)
+	}
+
+	if err = os.Remove(bakName(num)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := num - 1; i > 0; i-- {
+		if err = os.Rename(bakName(i), bakName(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(bakName(1), bts, fi.Mode().Perm()&perm, false)
+}
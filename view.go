@@ -0,0 +1,140 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/gookit/goutil/strutil"
+)
+
+// Sub create a view of the config, it's scoped to the given key prefix.
+//
+// The view shares the data and lock of the parent config, all key paths
+// will be resolved relative to the prefix. so changes in the parent are
+// visible through the view without copying.
+//
+// Usage:
+//
+//	dbConf := config.Sub("db")
+//	host := dbConf.String("host") // same as config.String("db.host")
+//
+// The loaders of the view load data under the prefix, eg: view.LoadStrings() and
+// view.LoadData(). the layer operations SetLayer, RemoveLayer, ReloadLayer are
+// not allowed on the view, and will return error.
+//
+// NOTICE: set hook for the view by view.Options().HookFunc, it will be
+// fired on data changed through the view. and view.Subscribe() will
+// receive the events related to the view keys.
+func Sub(key string) *Config { return dc.Sub(key) }
+
+// Sub create a view of the config, it's scoped to the given key prefix.
+func (c *Config) Sub(key string) *Config {
+	if key = c.viewKey(key); key == "" {
+		return c
+	}
+
+	// a view has own options, but not inherit the hook func and auto save.
+	v := c.handle(key, c.ctx)
+	v.opts.HookFunc = nil
+	return v
+}
+
+// IsView check the config is a view of other config, see Sub()
+func (c *Config) IsView() bool {
+	return c.prefix != ""
+}
+
+// Prefix get the key prefix of the view. will return empty on is not a view.
+func (c *Config) Prefix() string {
+	return c.prefix
+}
+
+// build the full key path in the root config
+func (c *Config) viewKey(key string) string {
+	sep := string(c.opts.Delimiter)
+	if key = formatKey(key, sep); key == "" || c.prefix == "" {
+		return key
+	}
+	return c.prefix + sep + key
+}
+
+// the top keys of the view data in the root config
+func (c *Config) rootKeys(keys []string) []string {
+	if c.prefix == "" || len(keys) == 0 {
+		return keys
+	}
+	return []string{strings.SplitN(c.prefix, string(c.opts.Delimiter), 2)[0]}
+}
+
+// get the root config of the view or handle
+func (c *Config) rootConfig() *Config {
+	if c.root != nil {
+		return c.root
+	}
+	return c
+}
+
+// create a handle of the root config, it shares all data with the root.
+// the prefix is the key prefix of the view, ctx is the load context.
+func (c *Config) handle(prefix string, ctx loadCtx) *Config {
+	// the handle has own options, but not inherit the auto save.
+	// the hook func of the root is fired by the root self.
+	opts := *c.opts
+	opts.AutoSave = nil
+	if c.root == nil {
+		opts.HookFunc = nil
+	}
+
+	return &Config{
+		name:   c.rootConfig().name,
+		opts:   &opts,
+		root:   c.rootConfig(),
+		prefix: prefix,
+		ctx:    ctx,
+	}
+}
+
+// fire the event on the root config, and call the hook of the view or handle.
+// the keys are relative to the view prefix.
+func (c *Config) notify(name, source string, keys ...string) {
+	if c.root != nil {
+		c.root.fireEvent(name, source, c.rootKeys(keys)...)
+	}
+	c.fireEvent(name, source, keys...)
+}
+
+// get the sub data of the view from root config
+func (c *Config) viewData() map[string]interface{} {
+	root := c.root
+	if c.prefix == "" {
+		return root.Data()
+	}
+	if !root.opts.Readonly {
+		root.lock.RLock()
+		defer root.lock.RUnlock()
+	}
+
+	val, ok := root.getValue(c.prefix)
+	if !ok {
+		return nil
+	}
+
+	// NOTICE: don't decrypt values, same as Config.Data()
+	if root.opts.ParseRefs {
+		var err error
+		if val, err = root.resolveValue(c.prefix, val, false); err != nil {
+			root.addError(err)
+		}
+	}
+
+	switch typeData := val.(type) {
+	case map[string]interface{}:
+		return typeData
+	case map[interface{}]interface{}: // decode from yaml
+		mp := make(map[string]interface{}, len(typeData))
+		for k, v := range typeData {
+			mp[strutil.MustString(k)] = v
+		}
+		return mp
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gookit/goutil/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Sub(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	err := c.LoadStrings(JSON, `{
+	"name": "app",
+	"db": {"host": "localhost", "port": 3306, "opts": {"debug": true}}
+}`)
+	is.NoError(err)
+
+	db := c.Sub("db")
+	is.True(db.IsView())
+	is.Equal("db", db.Prefix())
+	is.False(db.IsEmpty())
+	is.Equal("localhost", db.String("host"))
+	is.Equal(3306, db.Int("port"))
+	is.True(db.Exists("opts.debug"))
+	is.False(db.Exists("name"))
+	is.Len(db.Data(), 3)
+
+	// nested view
+	opts := db.Sub("opts")
+	is.Equal("db.opts", opts.Prefix())
+	is.True(opts.Bool("debug"))
+
+	// bind struct
+	st := struct {
+		Host string
+		Port int
+	}{}
+	is.NoError(db.Structure("", &st))
+	is.Equal("localhost", st.Host)
+	is.Equal(3306, st.Port)
+
+	// changes in the parent are visible
+	is.NoError(c.Set("db.host", "127.0.0.1"))
+	is.Equal("127.0.0.1", db.String("host"))
+
+	// set through the view
+	var event string
+	db.Options().HookFunc = func(e string, _ *Config) {
+		event = e
+	}
+	is.NoError(db.Set("port", 3307))
+	is.Equal(OnSetValue, event)
+	is.Equal(3307, c.Int("db.port"))
+	is.Error(db.Set("", 1))
+
+	buf := new(bytes.Buffer)
+	_, err = db.DumpTo(buf, JSON)
+	is.NoError(err)
+	is.Contains(buf.String(), `"port":3307`)
+
+	// not exists prefix
+	none := c.Sub("not-exist")
+	is.True(none.IsEmpty())
+	is.Equal("def", none.String("key", "def"))
+	is.Equal(c, c.Sub(""))
+}
+
+func TestConfig_Sub_cache(t *testing.T) {
+	is := assert.New(t)
+
+	c := NewWithOptions("test", EnableCache)
+	is.NoError(c.LoadStrings(JSON, `{"db": {"host": "a", "tags": ["a"], "opts": {"ssl": "on"}}}`))
+
+	db := c.Sub("db")
+	is.Equal("a", db.String("host"))
+	is.Equal([]string{"a"}, db.Strings("tags"))
+	is.Equal(map[string]string{"ssl": "on"}, db.StringMap("opts"))
+
+	// the view use the root cache
+	is.Nil(db.strCache)
+	is.Equal("a", c.strCache["db.host"])
+
+	// the caches are cleared on the root changed
+	is.NoError(c.Set("db.host", "b"))
+	is.NoError(c.Set("db.tags", []string{"b"}))
+	is.NoError(c.LoadStrings(JSON, `{"db": {"opts": {"ssl": "off"}}}`))
+	is.Equal("b", db.String("host"))
+	is.Equal("b", c.String("db.host"))
+	is.Equal([]string{"b"}, db.Strings("tags"))
+	is.Equal(map[string]string{"ssl": "off"}, db.StringMap("opts"))
+
+	is.NoError(db.Set("host", "c"))
+	is.Equal("c", c.String("db.host"))
+	is.Equal("c", db.String("host"))
+}
+
+func TestConfig_Sub_loaders(t *testing.T) {
+	is := assert.New(t)
+
+	c := New("test")
+	is.NoError(c.LoadStrings(JSON, `{"name": "app", "db": {"host": "localhost"}}`))
+
+	var keys []string
+	c.Subscribe(OnLoadData, func(e *Event) {
+		keys = append(keys, e.Keys...)
+	})
+
+	// load data under the view prefix
+	db := c.Sub("db")
+	is.NoError(db.LoadStrings(JSON, `{"port": 3306}`))
+	is.NoError(db.LoadData(map[string]interface{}{"user": "root"}))
+	is.Equal(3306, c.Int("db.port"))
+	is.Equal(3306, db.Int("port"))
+	is.Equal("root", c.String("db.user"))
+	is.True(db.Exists("user"))
+	is.False(c.Exists("port"))
+	is.Equal([]string{"db", "db"}, keys)
+
+	testutil.MockEnvValue("TEST_DB_PASS", "secret", func(_ string) {
+		is.NoError(db.LoadEnvPrefix("TEST_DB_"))
+	})
+	is.Equal("secret", c.String("db.pass"))
+	is.Equal(LayerEnv, c.Origin("db.pass"))
+
+	err := db.MergeWith(MergeOptions{TypeConflict: ConflictKeep}, func(v *Config) error {
+		is.Equal("db", v.Prefix())
+		return v.LoadData(map[string]interface{}{"host": map[string]interface{}{"name": "db.local"}, "ssl": true})
+	})
+	is.NoError(err)
+	is.Equal("localhost", c.String("db.host"))
+	is.True(c.Bool("db.ssl"))
+
+	// the whole layer operations are not allowed on the view
+	is.Error(db.SetLayer(LayerFiles, map[string]interface{}{}))
+	is.Error(db.RemoveLayer(LayerFiles))
+	is.Error(db.ReloadLayer(LayerFiles, func(*Config) error { return nil }))
+	is.Equal("app", c.String("name"))
+
+	// clear the data under the prefix only
+	is.NoError(c.Set("db.port", 3307))
+	db.ClearData()
+	is.True(db.IsEmpty())
+	is.False(c.Exists("db"))
+	is.Equal("app", c.String("name"))
+	is.Equal("", c.Origin("db.port"))
+}
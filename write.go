@@ -19,13 +19,41 @@ func SetData(data map[string]interface{}) {
 	dc.SetData(data)
 }
 
-// SetData for override the Config.Data
+// SetData for override the Config.Data.
+//
+// The data of all layers is replaced by the data, except the default values in LayerDefaults.
 func (c *Config) SetData(data map[string]interface{}) {
-	c.lock.Lock()
-	c.data = data
-	c.lock.Unlock()
+	// the view set the data under the prefix
+	if c.IsView() {
+		if err := c.setKey(c.prefix, data, true); err != nil {
+			c.root.addError(err)
+		}
+		return
+	}
 
-	c.fireHook(OnSetData)
+	root := c.rootConfig()
+	layer := c.layerFor(LayerFiles)
+	root.lock.Lock()
+	defaults, defMo := root.layers[LayerDefaults], root.layerMerge[LayerDefaults]
+	root.layers = map[string]map[string]interface{}{
+		layer: deepCopyMap(data),
+	}
+	root.layerMerge = nil
+
+	if layer == LayerDefaults || len(defaults) == 0 {
+		root.data = data
+	} else {
+		root.layers[LayerDefaults] = defaults
+		root.setLayerMerge(LayerDefaults, defMo)
+		if err := root.rebuildData(); err != nil {
+			root.addError(err)
+			delete(root.layers, LayerDefaults)
+			root.data = data
+		}
+	}
+	root.lock.Unlock()
+
+	c.notify(OnSetData, layer)
 }
 
 // Set val by key
@@ -35,27 +63,67 @@ func Set(key string, val interface{}, setByPath ...bool) error {
 
 // Set a value by key string.
 func (c *Config) Set(key string, val interface{}, setByPath ...bool) (err error) {
-	if c.opts.Readonly {
+	if key = formatKey(key, string(c.opts.Delimiter)); key == "" {
+		return errKeyIsEmpty
+	}
+	return c.setKey(c.viewKey(key), val, len(setByPath) == 0 || setByPath[0])
+}
+
+// set a value by the full key path in the root config, to the layer of the load context.
+func (c *Config) setKey(key string, val interface{}, byPath bool) (err error) {
+	root := c.rootConfig()
+	if root.opts.Readonly {
 		return errReadonly
 	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	layer := c.layerFor(LayerRuntime)
+	root.lock.Lock()
+	err = root.setToLayer(layer, key, val, byPath)
+	root.lock.Unlock()
+
+	// fire event after unlock, so the handlers can read or write the config.
+	if err == nil {
+		root.fireEvent(OnSetValue, layer, key)
+		if c.root != nil {
+			c.fireEvent(OnSetValue, layer, key)
+		}
+	}
+	return
+}
+
+// the merge options for the layer written by Set(), the value overrides the lower layers on rebuild data.
+var setMergeOptions = &MergeOptions{}
 
+// set a value to the layer, and update the config data.
+func (c *Config) setToLayer(layer, key string, val interface{}, byPath bool) (err error) {
 	sep := c.opts.Delimiter
-	if key = formatKey(key, string(sep)); key == "" {
-		return errKeyIsEmpty
+	if err = setValue(c.seedLayer(layer, key, byPath), key, val, sep, byPath); err != nil {
+		return
 	}
 
-	defer c.fireHook(OnSetValue)
+	// the set value is an explicit override, not merged by the options for loading. see WithMerge()
+	c.setLayerMerge(layer, setMergeOptions)
+
+	if layer == LayerRuntime {
+		c.recordChange(key)
+	}
+
+	if c.isTopLayer(layer) {
+		return setValue(c.data, key, deepCopyValue(val), sep, byPath)
+	}
+	return c.rebuildData()
+}
+
+// set a value to the data by key string.
+func setValue(data map[string]interface{}, key string, val interface{}, sep byte, byPath bool) (err error) {
 	if strings.IndexByte(key, sep) == -1 {
-		c.data[key] = val
+		data[key] = val
 		return
 	}
 
 	// disable set by path.
-	if len(setByPath) > 0 && !setByPath[0] {
-		c.data[key] = val
+	if !byPath {
+		data[key] = val
 		return
 	}
 
@@ -67,9 +135,9 @@ func (c *Config) Set(key string, val interface{}, setByPath ...bool) (err error)
 	var item interface{}
 
 	// find top item data based on top key
-	if item, ok = c.data[topK]; !ok {
+	if item, ok = data[topK]; !ok {
 		// not found, is new add
-		c.data[topK] = buildValueByPath(paths, val)
+		data[topK] = buildValueByPath(paths, val)
 		return
 	}
 
@@ -89,7 +157,7 @@ func (c *Config) Set(key string, val interface{}, setByPath ...bool) (err error)
 			return
 		}
 
-		c.data[topK] = dstItem
+		data[topK] = dstItem
 	case map[string]interface{}: // from json,toml
 		// create a new item for the topK
 		newItem := buildValueByPath(paths, val)
@@ -99,7 +167,7 @@ func (c *Config) Set(key string, val interface{}, setByPath ...bool) (err error)
 			return
 		}
 
-		c.data[topK] = typeData
+		data[topK] = typeData
 	case []interface{}: // is array
 		index, err := strconv.Atoi(keys[1])
 		if len(keys) == 2 && err == nil {
@@ -107,14 +175,14 @@ func (c *Config) Set(key string, val interface{}, setByPath ...bool) (err error)
 				typeData[index] = val
 			}
 
-			c.data[topK] = typeData
+			data[topK] = typeData
 		} else {
 			err = errors.New("max allow 1 level for setting array value, current key: " + key)
 			return err
 		}
 	default:
 		// as a top key
-		c.data[key] = val
+		data[key] = val
 		// err = errors.New("not supported value type, cannot setting value for the key: " + key)
 	}
 	return
@@ -29,6 +29,17 @@ func TestSetData(t *testing.T) {
 	assert.False(t, c.Exists("name"))
 	assert.True(t, c.Exists("age"))
 	assert.Equal(t, 222, c.Int("age"))
+	ClearAll()
+
+	// the default values are kept
+	c = New("test")
+	assert.NoError(t, c.SetDefault("port", 80))
+	assert.NoError(t, c.Set("name", "app"))
+	c.SetData(map[string]interface{}{"age": 222})
+	assert.Equal(t, 80, c.Int("port"))
+	assert.Equal(t, 222, c.Int("age"))
+	assert.False(t, c.Exists("name"))
+	assert.Equal(t, LayerDefaults, c.Origin("port"))
 }
 
 func TestSet(t *testing.T) {
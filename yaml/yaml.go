@@ -8,6 +8,9 @@ package yaml
 
 // see https://pkg.go.dev/gopkg.in/yaml.v2
 import (
+	"bytes"
+	"io"
+
 	"github.com/gookit/config/v2"
 	"gopkg.in/yaml.v2"
 )
@@ -18,5 +21,24 @@ var Decoder config.Decoder = yaml.Unmarshal
 // Encoder the yaml content encoder
 var Encoder config.Encoder = yaml.Marshal
 
-// Driver for yaml
-var Driver = config.NewDriver(config.Yaml, Decoder, Encoder)
+// DocsDecoder decode all "---" separated documents in the yaml content
+var DocsDecoder config.DocsDecoder = func(blob []byte) (docs []map[string]interface{}, err error) {
+	dec := yaml.NewDecoder(bytes.NewReader(blob))
+	for {
+		var doc map[string]interface{}
+		if err = dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				return docs, nil
+			}
+			return nil, err
+		}
+
+		// skip empty document
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// Driver for yaml, on load content by the driver, all documents will be merged in order.
+var Driver = config.NewDocsDriver(config.Yaml, Decoder, Encoder, DocsDecoder)
@@ -0,0 +1,445 @@
+package yamlv3
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gookit/config/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// RoundTripDriver a yaml driver, it keeps the yaml.Node tree of the loaded content.
+// On encode data, the changes are applied to the node tree, so the comments,
+// key order and anchors of the content are kept.
+//
+// The driver keeps the node tree of each loaded file, the tree is matched by the file
+// path on save the file. see config.FileDriver. the Decode and Encode without file
+// path use the node tree of the last decoded content.
+//
+// NOTICE: the node trees are not changed on encode, please use a new driver for each config instance.
+//
+// Usage:
+//
+//	c := config.New("app")
+//	c.AddDriver(yamlv3.NewRoundTrip())
+//	err := c.LoadFiles("app.yml")
+//
+//	c.Set("db.port", 3307)
+//	err = c.DumpToFile("app.yml", config.Yaml) // only the "port" line is changed
+type RoundTripDriver struct {
+	lock sync.Mutex
+	// the last decoded content
+	last *nodeTree
+	// the decoded content of each file
+	files map[string]*nodeTree
+}
+
+// nodeTree the decoded yaml content
+type nodeTree struct {
+	// the document node of the content
+	doc *yaml.Node
+	// the source content, for restore blank lines
+	src []byte
+	// the indent spaces of the content
+	indent int
+}
+
+// NewRoundTrip create a new round-trip yaml driver
+func NewRoundTrip() *RoundTripDriver {
+	return &RoundTripDriver{}
+}
+
+// Name of the driver
+func (d *RoundTripDriver) Name() string {
+	return config.Yaml
+}
+
+// GetDecoder of the driver
+func (d *RoundTripDriver) GetDecoder() config.Decoder {
+	return d.Decode
+}
+
+// GetEncoder of the driver
+func (d *RoundTripDriver) GetEncoder() config.Encoder {
+	return d.Encode
+}
+
+// Decode the yaml content to v, and keep the node tree.
+func (d *RoundTripDriver) Decode(blob []byte, v interface{}) error {
+	return d.DecodeFile("", blob, v)
+}
+
+// DecodeFile decode the yaml content of the file to v, and keep the node tree for the file.
+func (d *RoundTripDriver) DecodeFile(file string, blob []byte, v interface{}) error {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(blob, doc); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	// is empty content
+	var tree *nodeTree
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		tree = &nodeTree{doc: doc, src: blob, indent: detectIndent(blob)}
+	}
+
+	d.last = tree
+	if file != "" {
+		if d.files == nil {
+			d.files = make(map[string]*nodeTree)
+		}
+		d.files[fileKey(file)] = tree
+	}
+
+	if tree == nil {
+		return nil
+	}
+	return doc.Decode(v)
+}
+
+// Encode the data. if the node tree exists, will apply the data to a copy of it and encode the node tree.
+func (d *RoundTripDriver) Encode(v interface{}) ([]byte, error) {
+	return d.EncodeFile("", v)
+}
+
+// EncodeFile encode the data for the file, will use the node tree of the file.
+// if the file has not been decoded, will use the node tree of the last decoded content.
+func (d *RoundTripDriver) EncodeFile(file string, v interface{}) ([]byte, error) {
+	d.lock.Lock()
+	tree := d.last
+	if t, ok := d.files[fileKey(file)]; ok && file != "" {
+		tree = t
+	}
+	d.lock.Unlock()
+
+	indent := 4
+	if tree != nil && tree.indent > 0 {
+		indent = tree.indent
+	}
+
+	var out interface{} = v
+	roundTrip := tree != nil && tree.doc.Content[0].Kind == yaml.MappingNode
+	if roundTrip {
+		// sync to a copy, the node tree is kept for encode other data. eg: redacted data
+		doc := copyNode(tree.doc, make(map[*yaml.Node]*yaml.Node))
+		if err := syncNode(doc.Content[0], v); err != nil {
+			return nil, err
+		}
+
+		fixMergeKeys(doc)
+		out = doc
+	}
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	if !roundTrip {
+		return buf.Bytes(), nil
+	}
+	return restoreBlankLines(tree.src, buf.Bytes()), nil
+}
+
+// get the key of the file for match the node tree
+func fileKey(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		return abs
+	}
+	return filepath.Clean(file)
+}
+
+// deep copy the node tree, the alias nodes are pointed to the copied anchor nodes.
+func copyNode(node *yaml.Node, copied map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if n, ok := copied[node]; ok {
+		return n
+	}
+
+	n := *node
+	copied[node] = &n
+
+	n.Alias = copyNode(node.Alias, copied)
+	if node.Content != nil {
+		n.Content = make([]*yaml.Node, len(node.Content))
+		for i, sub := range node.Content {
+			n.Content[i] = copyNode(sub, copied)
+		}
+	}
+	return &n
+}
+
+// apply the value to the node, will keep the node if the value is not changed.
+func syncNode(node *yaml.Node, val interface{}) error {
+	if nodeEquals(node, val) {
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if mp := toStringMap(val); mp != nil {
+			return syncMapping(node, mp)
+		}
+	case yaml.SequenceNode:
+		rv := reflect.ValueOf(val)
+		if rv.Kind() == reflect.Slice && rv.Len() == len(node.Content) {
+			for i, item := range node.Content {
+				if err := syncNode(item, rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return replaceNode(node, val)
+}
+
+// apply the map data to the mapping node.
+func syncMapping(node *yaml.Node, mp map[string]interface{}) error {
+	// the current value of the node, contains the keys from merge key "<<"
+	var current map[string]interface{}
+	if err := node.Decode(&current); err != nil {
+		return err
+	}
+
+	own := make(map[string]bool, len(node.Content)/2)
+	content := make([]*yaml.Node, 0, len(node.Content))
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+
+		// keep the merge key
+		if isMergeKey(keyNode) {
+			content = append(content, keyNode, valNode)
+			continue
+		}
+
+		val, ok := mp[key]
+		if !ok { // the key has been deleted
+			continue
+		}
+
+		if err := syncNode(valNode, val); err != nil {
+			return err
+		}
+
+		own[key] = true
+		content = append(content, keyNode, valNode)
+	}
+
+	// append new keys
+	newKeys := make([]string, 0)
+	for key, val := range mp {
+		if own[key] {
+			continue
+		}
+
+		// the key is from merge key "<<"
+		if cur, ok := current[key]; ok && valueEquals(cur, val) {
+			continue
+		}
+		newKeys = append(newKeys, key)
+	}
+	sort.Strings(newKeys)
+
+	for _, key := range newKeys {
+		valNode := &yaml.Node{}
+		if err := valNode.Encode(mp[key]); err != nil {
+			return err
+		}
+
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		content = append(content, keyNode, valNode)
+	}
+
+	node.Content = content
+	return nil
+}
+
+// replace the node by new value, will keep the comments and anchor of the node.
+func replaceNode(node *yaml.Node, val interface{}) error {
+	newNode := yaml.Node{}
+	if err := newNode.Encode(val); err != nil {
+		return err
+	}
+
+	// keep quote style for string
+	if node.Kind == yaml.ScalarNode && newNode.Kind == yaml.ScalarNode && node.Tag == newNode.Tag {
+		newNode.Style = node.Style
+	}
+
+	newNode.Anchor = node.Anchor
+	newNode.HeadComment = node.HeadComment
+	newNode.LineComment = node.LineComment
+	newNode.FootComment = node.FootComment
+	newNode.Line, newNode.Column = node.Line, node.Column
+
+	*node = newNode
+	return nil
+}
+
+func nodeEquals(node *yaml.Node, val interface{}) bool {
+	var current interface{}
+	if err := node.Decode(&current); err != nil {
+		return false
+	}
+	return valueEquals(current, val)
+}
+
+func valueEquals(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+// normalize the value for compare. number to float64, map to map[string]interface{}, slice to []interface{}
+func normalizeValue(val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	if mp := toStringMap(val); mp != nil {
+		newMp := make(map[string]interface{}, len(mp))
+		for k, v := range mp {
+			newMp[k] = normalizeValue(v)
+		}
+		return newMp
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		arr := make([]interface{}, rv.Len())
+		for i := range arr {
+			arr[i] = normalizeValue(rv.Index(i).Interface())
+		}
+		return arr
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return val
+}
+
+func toStringMap(val interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Map {
+		return nil
+	}
+
+	mp := make(map[string]interface{}, rv.Len())
+	for _, key := range rv.MapKeys() {
+		k, ok := key.Interface().(string)
+		if !ok {
+			return nil
+		}
+		mp[k] = rv.MapIndex(key).Interface()
+	}
+	return mp
+}
+
+// detect the indent spaces of the yaml content. will return 0 on not found.
+func detectIndent(blob []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(blob), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '-' {
+			continue
+		}
+
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+
+	if indent == 1 {
+		indent = 2
+	}
+	return indent
+}
+
+// the merge key "<<" will be encoded as "!!merge <<" by the yaml.v3, remove the tag for keep it.
+func fixMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i]; isMergeKey(key) {
+				key.Tag = ""
+			}
+		}
+	}
+
+	for _, sub := range node.Content {
+		fixMergeKeys(sub)
+	}
+}
+
+// the tag of merge key is empty after fixMergeKeys()
+func isMergeKey(node *yaml.Node) bool {
+	return node.Value == "<<" && (node.Tag == "!!merge" || node.Tag == "")
+}
+
+// restore the blank lines of the source content, the yaml.v3 drops them on encode.
+// the lines are matched by the key part, blank lines are kept before the matched line.
+func restoreBlankLines(src, out []byte) []byte {
+	srcLines := strings.Split(string(src), "\n")
+	outLines := strings.Split(string(out), "\n")
+	lines := make([]string, 0, len(outLines)+8)
+
+	pos := 0 // the position in the source lines
+	for _, line := range outLines {
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, line)
+			continue
+		}
+
+		key := lineKey(line)
+		for i := pos; i < len(srcLines); i++ {
+			if lineKey(srcLines[i]) != key {
+				continue
+			}
+
+			// copy the blank lines before the matched line
+			start := i
+			for start > pos && strings.TrimSpace(srcLines[start-1]) == "" {
+				start--
+			}
+			for ; start < i; start++ {
+				lines = append(lines, "")
+			}
+
+			pos = i + 1
+			break
+		}
+		lines = append(lines, line)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// get the key part of the yaml line. eg: "  port: 3306 # comment" => "  port:"
+func lineKey(line string) string {
+	line = strings.TrimRight(line, " ")
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return line
+	}
+
+	if pos := strings.Index(line, ": "); pos > 0 {
+		return line[:pos+1]
+	}
+	return line
+}
@@ -0,0 +1,168 @@
+package yamlv3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gookit/config/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var roundTripStr = `# app config
+name: app # the app name
+debug: true
+
+base: &base
+  host: localhost
+  port: 3306
+
+db:
+  <<: *base
+  # the db user
+  user: root
+  password: "123456"
+
+tags:
+  - a
+  - b
+`
+
+func TestRoundTripDriver(t *testing.T) {
+	is := assert.New(t)
+
+	c := config.NewEmpty("test")
+	c.AddDriver(NewRoundTrip())
+	is.NoError(c.LoadStrings(config.Yaml, roundTripStr))
+	is.Equal("app", c.String("name"))
+	is.Equal("localhost", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+
+	// not changed
+	buf := new(bytes.Buffer)
+	_, err := c.DumpTo(buf, config.Yaml)
+	is.NoError(err)
+	is.Equal(roundTripStr, strings.TrimSuffix(buf.String(), "\n"))
+
+	// changed
+	is.NoError(c.Set("db.user", "admin"))
+	is.NoError(c.Set("debug", false))
+	is.NoError(c.Set("db.password", "654321"))
+	is.NoError(c.Set("tags", []string{"a", "b", "c"}))
+	is.NoError(c.Set("new_key", "val"))
+
+	buf.Reset()
+	_, err = c.DumpTo(buf, config.Yaml)
+	is.NoError(err)
+
+	want := strings.NewReplacer(
+		"debug: true", "debug: false",
+		"user: root", "user: admin",
+		`password: "123456"`, `password: "654321"`,
+		"  - b\n", "  - b\n  - c\nnew_key: val\n",
+	).Replace(roundTripStr)
+	is.Equal(want, strings.TrimSuffix(buf.String(), "\n"))
+
+	// delete key
+	d := NewRoundTrip()
+	c2 := config.NewEmpty("test")
+	c2.AddDriver(d)
+	is.NoError(c2.LoadStrings(config.Yaml, roundTripStr))
+	data := make(map[string]interface{})
+	for k, v := range c2.Data() {
+		data[k] = v
+	}
+	delete(data, "tags")
+
+	bts, err := d.Encode(data)
+	is.NoError(err)
+	is.NotContains(string(bts), "tags")
+	is.Contains(string(bts), "# the db user")
+}
+
+func TestRoundTripDriver_saveFile(t *testing.T) {
+	is := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "app.yml")
+	is.NoError(ioutil.WriteFile(file, []byte(roundTripStr), 0644))
+
+	c := config.NewEmpty("test")
+	c.WithOptions(config.WithSetSaveFile(file, config.Yaml))
+	c.AddDriver(NewRoundTrip())
+	is.NoError(c.LoadFiles(file))
+
+	is.NoError(c.Set("db.port", 3307))
+	is.NoError(c.Set("name", "my-app"))
+
+	bts, err := ioutil.ReadFile(file)
+	is.NoError(err)
+
+	want := strings.NewReplacer(
+		"name: app #", "name: my-app #",
+		"\"123456\"\n", "\"123456\"\n  port: 3307\n",
+	).Replace(roundTripStr)
+	is.Equal(want, string(bts))
+}
+
+func TestRoundTripDriver_noContent(t *testing.T) {
+	is := assert.New(t)
+
+	d := NewRoundTrip()
+	is.Equal(config.Yaml, d.Name())
+
+	data := map[string]interface{}{}
+	is.NoError(d.GetDecoder()([]byte(""), &data))
+	is.Len(data, 0)
+
+	bts, err := d.GetEncoder()(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+	is.NoError(err)
+	is.Equal("db:\n    host: localhost\n", string(bts))
+
+	is.Error(d.Decode([]byte("invalid: yaml: content"), &data))
+}
+
+func TestDetectIndent(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(0, detectIndent([]byte("name: app\n")))
+	is.Equal(2, detectIndent([]byte("db:\n  host: localhost\n")))
+	is.Equal(4, detectIndent([]byte("db:\n    host: localhost\n    # comment\n")))
+	is.Equal(2, detectIndent([]byte("db:\n host: localhost\n")))
+}
+
+func TestRoundTripDriver_multiFiles(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	appFile := filepath.Join(dir, "app.yml")
+	dbFile := filepath.Join(dir, "db.yml")
+	dbStr := "# db config\ndb:\n  host: localhost # the db host\n  password: s3cret\n"
+	is.NoError(ioutil.WriteFile(appFile, []byte("# app config\nname: app # the app name\n"), 0644))
+	is.NoError(ioutil.WriteFile(dbFile, []byte(dbStr), 0644))
+
+	c := config.NewEmpty("test")
+	c.AddDriver(NewRoundTrip())
+	c.AddSensitive("*.password")
+	is.NoError(c.LoadFiles(appFile, dbFile))
+
+	// the redacted dump will not change the node tree
+	buf := new(bytes.Buffer)
+	_, err := c.DumpTo(buf, config.Yaml)
+	is.NoError(err)
+	is.Contains(buf.String(), "******")
+
+	// each file is saved with own node tree
+	is.NoError(c.Set("name", "my-app"))
+	is.NoError(c.Set("db.host", "db.local"))
+	is.NoError(c.SaveChanges())
+
+	bts, err := ioutil.ReadFile(appFile)
+	is.NoError(err)
+	is.Equal("# app config\nname: my-app # the app name\n", string(bts))
+
+	bts, err = ioutil.ReadFile(dbFile)
+	is.NoError(err)
+	is.Equal(strings.Replace(dbStr, "host: localhost", "host: db.local", 1), string(bts))
+}
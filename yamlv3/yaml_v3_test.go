@@ -166,3 +166,51 @@ command: ${ APP_COMMAND | app:run }
 	ris.Equal("", config.Getenv("APP_COMMAND"))
 	ris.Equal("app:run", c.String("command"))
 }
+
+func TestLoadDocuments(t *testing.T) {
+	is := assert.New(t)
+
+	src := `
+name: app
+debug: true
+db:
+  host: localhost
+  port: 3306
+---
+profile: dev
+db:
+  host: dev.local
+---
+profile: prod
+debug: false
+db:
+  host: prod.local
+---
+`
+
+	// all documents are merged in order
+	c := config.NewEmpty("test")
+	c.AddDriver(Driver)
+	is.NoError(c.LoadStrings(config.Yaml, src))
+	is.Equal("app", c.String("name"))
+	is.Equal("prod", c.String("profile"))
+	is.Equal("prod.local", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.False(c.Bool("debug"))
+
+	// select documents
+	c = config.NewEmpty("test")
+	c.AddDriver(Driver)
+	is.NoError(c.LoadDocuments(config.Yaml, []byte(src), config.SelectDocs("profile", "dev")))
+	is.False(c.Exists("profile"))
+	is.Equal("dev.local", c.String("db.host"))
+	is.Equal(3306, c.Int("db.port"))
+	is.True(c.Bool("debug"))
+
+	docs, err := DocsDecoder([]byte(src))
+	is.NoError(err)
+	is.Len(docs, 3)
+
+	_, err = DocsDecoder([]byte("name: app\n---\n: invalid: yaml"))
+	is.Error(err)
+}